@@ -3,16 +3,16 @@ module id3v2-tui
 go 1.25.6
 
 require (
+	github.com/bogem/id3v2 v1.2.0
 	github.com/gdamore/tcell/v2 v2.13.8
 	github.com/rivo/tview v0.42.0
+	golang.org/x/text v0.31.0
 )
 
 require (
-	github.com/bogem/id3v2 v1.2.0 // indirect
 	github.com/gdamore/encoding v1.0.1 // indirect
 	github.com/lucasb-eyer/go-colorful v1.3.0 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
 	golang.org/x/sys v0.38.0 // indirect
 	golang.org/x/term v0.37.0 // indirect
-	golang.org/x/text v0.31.0 // indirect
 )
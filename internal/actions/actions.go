@@ -0,0 +1,116 @@
+package actions
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"id3v2-tui/internal/keymap"
+)
+
+type Registry struct {
+	names    []string
+	handlers map[string]func()
+}
+
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[string]func())}
+}
+
+func (r *Registry) Register(name string, run func()) {
+	if run == nil {
+		return
+	}
+	if _, ok := r.handlers[name]; !ok {
+		r.names = append(r.names, name)
+	}
+	r.handlers[name] = run
+}
+
+func (r *Registry) Has(name string) bool {
+	return r.handlers[name] != nil
+}
+
+func (r *Registry) Run(name string) bool {
+	run := r.handlers[name]
+	if run == nil {
+		return false
+	}
+	run()
+	return true
+}
+
+func (r *Registry) Names() []string {
+	return append([]string(nil), r.names...)
+}
+
+func Description(name string) string {
+	if a, ok := keymap.LookupAction(name); ok {
+		return a.Description
+	}
+	return name
+}
+
+func isBoundary(prev rune) bool {
+	return unicode.IsSpace(prev) || prev == '_' || prev == '-' || prev == '/'
+}
+
+func Score(pattern, text string) (int, bool) {
+	pattern = strings.ToLower(strings.Join(strings.Fields(strings.ReplaceAll(pattern, "_", " ")), ""))
+	if pattern == "" {
+		return 0, true
+	}
+
+	target := []rune(strings.ToLower(text))
+	score, last := 0, -1
+	p := []rune(pattern)
+	for i, j := 0, 0; j < len(p); i++ {
+		if i == len(target) {
+			return 0, false
+		}
+		if target[i] != p[j] {
+			continue
+		}
+		score++
+		switch {
+		case i == 0 || isBoundary(target[i-1]):
+			score += 8
+		case last == i-1:
+			score += 5
+		}
+		if last >= 0 {
+			score -= min(i-last-1, 3)
+		}
+		last = i
+		j++
+	}
+	return score, true
+}
+
+func (r *Registry) Search(query string) []string {
+	type match struct {
+		name  string
+		score int
+	}
+	var matches []match
+	for _, name := range r.names {
+		best, found := 0, false
+		for _, text := range []string{Description(name), strings.ReplaceAll(name, "_", " ")} {
+			if score, ok := Score(query, text); ok && (!found || score > best) {
+				best, found = score, true
+			}
+		}
+		if found {
+			matches = append(matches, match{name, best})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	names := make([]string, len(matches))
+	for i, m := range matches {
+		names[i] = m.name
+	}
+	return names
+}
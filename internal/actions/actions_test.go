@@ -0,0 +1,73 @@
+package actions
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	var ran []string
+	r.Register("save", func() { ran = append(ran, "save") })
+	r.Register("rename", nil)
+	r.Register("clear_form", func() { ran = append(ran, "clear") })
+	r.Register("save", func() { ran = append(ran, "save2") })
+
+	if strings.Join(r.Names(), ",") != "save,clear_form" {
+		t.Errorf("unexpected names %v", r.Names())
+	}
+	if r.Has("rename") {
+		t.Error("nil handlers should not be registered")
+	}
+	if !r.Run("save") || r.Run("rename") {
+		t.Error("unexpected Run result")
+	}
+	if strings.Join(ran, ",") != "save2" {
+		t.Errorf("re-registering should replace the handler, ran %v", ran)
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		pattern, text string
+		ok            bool
+	}{
+		{"", "Save the form", true},
+		{"save", "Save the form", true},
+		{"stf", "Save the form", true},
+		{"exp cov", "Export the cover picture", true},
+		{"xyz", "Save the form", false},
+		{"formsave", "Save the form", false},
+	}
+	for _, tt := range tests {
+		if _, ok := Score(tt.pattern, tt.text); ok != tt.ok {
+			t.Errorf("Score(%q, %q) matched = %v, want %v", tt.pattern, tt.text, ok, tt.ok)
+		}
+	}
+
+	prefix, _ := Score("ren", "Rename files from tags")
+	scattered, _ := Score("ren", "Fix mis-decoded text encoding")
+	if prefix <= scattered {
+		t.Errorf("word prefix should score higher: %d <= %d", prefix, scattered)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	r := NewRegistry()
+	for _, name := range []string{"save", "clear_form", "export_csv", "export_cover", "rename", "undo_rename"} {
+		r.Register(name, func() {})
+	}
+
+	if got := r.Search(""); len(got) != 6 || got[0] != "save" {
+		t.Errorf("an empty query should list every action in order, got %v", got)
+	}
+	if got := r.Search("cover"); len(got) != 1 || got[0] != "export_cover" {
+		t.Errorf("unexpected matches %v", got)
+	}
+	if got := r.Search("ren"); len(got) < 2 || got[0] != "rename" {
+		t.Errorf("rename should rank first, got %v", got)
+	}
+	if got := r.Search("undo_ren"); len(got) == 0 || got[0] != "undo_rename" {
+		t.Errorf("action names should match too, got %v", got)
+	}
+}
@@ -6,31 +6,106 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 
+	"id3v2-tui/internal/commands"
+	"id3v2-tui/internal/config"
 	"id3v2-tui/internal/files"
+	"id3v2-tui/internal/hooks"
+	"id3v2-tui/internal/keymap"
+	"id3v2-tui/internal/library"
 	"id3v2-tui/internal/metadata"
 	"id3v2-tui/internal/modals"
+	"id3v2-tui/internal/rename"
+	"id3v2-tui/internal/theme"
 	"id3v2-tui/internal/ui"
+	"id3v2-tui/internal/vim"
 )
 
 type App struct {
-	app          *tview.Application
-	fileList     *tview.List
-	form         *tview.Form
-	pages        *tview.Pages
-	root         tview.Primitive
-	meta         *metadata.Metadata
-	originalMeta *metadata.Metadata
-	currentDir   string
-	currentFile  string
-	focusIndex   int
+	app            *tview.Application
+	fileList       *tview.List
+	library        *ui.LibraryTable
+	browser        *tview.Flex
+	tasks          map[string]*task
+	entries        map[string]library.Entry
+	items          map[string]files.Item
+	filter         library.Query
+	views          *files.Views
+	view           files.View
+	index          *library.Index
+	startErrs      []error
+	reselect       string
+	form           *tview.Form
+	pages          *tview.Pages
+	root           tview.Primitive
+	meta           *metadata.Metadata
+	originalMeta   *metadata.Metadata
+	currentDir     string
+	currentFile    string
+	focusIndex     int
+	renameTemplate string
+	renameHistory  []rename.Plan
+	selected       map[string]bool
+	executor       commands.Executor
+	hooks          hooks.Hooks
+	layout         *tview.Flex
+	logPanel       *tview.TextView
+	logVisible     bool
+	formFields     []string
+	startDir       string
+	keymap         *keymap.Keymap
+	vim            *vim.State
+	statusBar      *ui.StatusBar
+	statusHints    string
+	cmdline        *ui.CommandLine
+	modified       bool
+	confirmSave    bool
+	batch          []string
+	keep           map[string]bool
+	readErr        error
+	overwrite      bool
 }
 
 func NewApp() *App {
 	return &App{
-		meta:         &metadata.Metadata{},
-		originalMeta: &metadata.Metadata{},
+		meta:           &metadata.Metadata{},
+		originalMeta:   &metadata.Metadata{},
+		renameTemplate: rename.DefaultTemplate,
+		selected:       make(map[string]bool),
+		executor:       commands.NewExecutor(),
+		hooks:          hooks.FromEnv(),
+		formFields:     config.DefaultFormFields,
+		keymap:         keymap.Default(),
+		confirmSave:    true,
+		views:          newViews(files.DefaultView()),
+	}
+}
+
+func newViews(fallback files.View) *files.Views {
+	views, _ := files.LoadViews("", fallback)
+	return views
+}
+
+func (a *App) SetConfig(cfg *config.Config) {
+	a.formFields = cfg.FormFields
+	a.startDir = cfg.StartDir
+	a.keymap = cfg.Keymap
+	a.confirmSave = cfg.ConfirmSave
+	var err error
+	if a.views, err = files.LoadViews(config.StatePath("views.json"), cfg.Browser); err != nil {
+		a.startErrs = append(a.startErrs, err)
+	}
+	if a.index, err = library.OpenIndex(library.IndexPath); err != nil {
+		a.startErrs = append(a.startErrs, err)
+	}
+	modals.Keys = cfg.Keymap
+	if cfg.Vim {
+		a.vim = &vim.State{}
+	}
+	for event, cmds := range cfg.Hooks {
+		a.hooks[event] = append(a.hooks[event], cmds...)
 	}
 }
 
@@ -54,6 +129,10 @@ func (a *App) getFileList() *tview.List {
 	return a.fileList
 }
 
+func (a *App) getCurrentFile() string {
+	return a.currentFile
+}
+
 func (a *App) getCurrentDir() string {
 	return a.currentDir
 }
@@ -70,37 +149,41 @@ func (a *App) setFocusIndex(idx int) {
 	a.focusIndex = idx
 }
 
-func (a *App) saveMetadata(filePath, trackName, artist, album, coverPath string) (string, error) {
-	newMeta := &metadata.Metadata{
-		TrackName: trackName,
-		Artist:    artist,
-		Album:     album,
-		CoverPath: coverPath,
-	}
-
-	diff := a.originalMeta.Diff(newMeta)
+func (a *App) quit() {
+	a.confirmDiscard(a.app.Stop)
+}
 
-	err := metadata.Save(filePath, newMeta)
-	if err != nil {
-		return "", err
-	}
+func (a *App) changeDirectory(dir string) {
+	a.confirmDiscard(func() {
+		previous := a.currentDir
+		a.loadFiles(dir)
+		if filepath.Dir(previous) == a.currentDir {
+			a.reselect = filepath.Base(previous) + "/"
+		}
+	})
+}
 
-	a.meta = newMeta
-	return diff, nil
+func (a *App) nextTheme() {
+	theme.Set(theme.Next())
+	theme.Apply(a.root)
+	a.logf("theme: %s", tview.Escape(theme.Current().Name))
 }
 
-func (a *App) readMetadata(filePath string) error {
-	meta, err := metadata.Read(filePath)
-	if err != nil {
-		a.meta = &metadata.Metadata{}
-		return nil
-	}
-	a.meta = meta
-	return nil
+func (a *App) showHelp(text string) {
+	modals.ShowHelp(a.app, a.root, text)
 }
 
-func (a *App) loadFiles(dir string) {
-	a.currentDir = files.Load(a.fileList, dir)
+func (a *App) detectColors() {
+	detected := false
+	a.app.SetBeforeDrawFunc(func(screen tcell.Screen) bool {
+		if !detected {
+			detected = true
+			if theme.SetColors(screen.Colors()) {
+				theme.Apply(a.root)
+			}
+		}
+		return false
+	})
 }
 
 func (a *App) Run(filePath string) error {
@@ -111,69 +194,137 @@ func (a *App) Run(filePath string) error {
 	}
 
 	ctx := &ui.UIContext{
-		App:           a.app,
-		GetRoot:       a.getRoot,
-		ShowError:     a.showError,
-		ShowMessage:   a.showMessage,
-		GetForm:       a.getForm,
-		GetFileList:   a.getFileList,
-		GetCurrentDir: a.getCurrentDir,
-		SetCurrentDir: a.setCurrentDir,
-		GetFocusIndex: a.getFocusIndex,
-		SetFocusIndex: a.setFocusIndex,
-		SaveMetadata:  a.saveMetadata,
+		App:             a.app,
+		GetRoot:         a.getRoot,
+		ShowError:       a.showError,
+		ShowMessage:     a.showMessage,
+		GetForm:         a.getForm,
+		GetFileList:     a.getFileList,
+		GetBrowser:      a.getBrowser,
+		GetCurrentFile:  a.getCurrentFile,
+		GetCurrentDir:   a.getCurrentDir,
+		SetCurrentDir:   a.setCurrentDir,
+		GetFocusIndex:   a.getFocusIndex,
+		SetFocusIndex:   a.setFocusIndex,
+		SaveMetadata:    a.saveMetadata,
+		RenameFromTags:  a.renameFromTags,
+		UndoRename:      a.undoRename,
+		ToggleSelection: a.toggleSelection,
+		FindReplace:     a.findReplace,
+		TextActions:     a.textActions,
+		FixEncoding:     a.fixEncoding,
+		NumberTracks:    a.numberTracks,
+		ExportCSV:       a.exportCSV,
+		ImportCSV:       a.importCSV,
+		OpenInEditor:    a.openInEditor,
+		ExportCover:     a.exportCover,
+		ConvertVersion:  a.convertVersion,
+		StripTags:       a.stripTags,
+		ToggleLibrary:   a.toggleLibrary,
+		Filter:          a.filterBrowser,
+		Sort:            a.sortBrowser,
+		ToggleHidden:    a.toggleHidden,
+		EditSelection:   a.editSelection,
+		SaveBatch:       a.saveBatch,
+		ToggleLog:       a.toggleLog,
+		NextTheme:       a.nextTheme,
+		ShowHelp:        a.showHelp,
+		ShowPalette:     a.showPalette,
+		Quit:            a.quit,
+		FormChanged:     a.updateModified,
+		Keymap:          a.keymap,
+		Vim:             a.vim,
+		ModeChanged:     a.refreshStatus,
+		OpenCommandLine: a.openCommandLine,
+		LoadDirectory:   a.changeDirectory,
+		PreviewRename:   a.previewRename,
+		FormFields:      a.formFields,
+	}
+	if a.confirmSave {
+		ctx.ConfirmSave = a.confirmChanges
 	}
 
 	a.fileList = ui.CreateFileBrowser(ctx)
 	a.form = ui.CreateMetadataForm(false, ctx)
+	a.createLibrary()
 
-	currentDir, _ := os.Getwd()
-	a.currentDir = currentDir
-	a.loadFiles(currentDir)
+	currentDir := a.startDir
+	if currentDir == "" {
+		currentDir, _ = os.Getwd()
+	}
 
-	statusBar := ui.CreateStatusBar("↑↓ Navigate | Enter: Open | Tab/Shift+Tab: Cycle | Esc: Clear | q: Quit")
+	navigation := "↑↓ Navigate | Enter: Open"
+	if a.vim != nil {
+		navigation = "j/k: Navigate | h/l: Directories | i: Insert | /: Search | :w :q :rename"
+	}
+	a.statusBar = ui.CreateStatusBar("")
+	a.cmdline = ui.CreateCommandLine()
+	a.setStatus(a.statusText(navigation, keymap.ContextList, []hint{
+		{"focus_next", "Cycle"},
+		{"clear_form", "Clear"},
+		{"toggle_selection", "Select"},
+		{"edit_selection", "Edit selected"},
+		{"toggle_library", "Library"},
+		{"filter", "Filter"},
+		{"sort", "Sort"},
+		{"toggle_hidden", "Hidden"},
+		{"find_replace", "Replace"},
+		{"text_actions", "Text"},
+		{"fix_encoding", "Encoding"},
+		{"number_tracks", "Number"},
+		{"export_csv", "Export"},
+		{"import_csv", "Import"},
+		{"edit_in_editor", "Editor"},
+		{"toggle_log", "Log"},
+		{"next_theme", "Theme"},
+		{"rename", "Rename"},
+		{"undo_rename", "Undo"},
+		{"command_palette", "Palette"},
+		{"help", "Help"},
+		{"quit", "Quit"},
+	}))
+	a.logPanel = ui.CreateLogPanel()
+	a.browser = tview.NewFlex().SetDirection(tview.FlexColumn).
+		AddItem(a.fileList, 0, 1, true).
+		AddItem(a.form, 0, 2, false)
 	mainFlex := tview.NewFlex().SetDirection(tview.FlexRow).
-		AddItem(tview.NewFlex().SetDirection(tview.FlexColumn).
-			AddItem(a.fileList, 0, 1, true).
-			AddItem(a.form, 0, 2, false), 0, 1, true).
-		AddItem(statusBar, 1, 0, false)
+		AddItem(a.browser, 0, 1, true).
+		AddItem(a.logPanel, 0, 0, false).
+		AddItem(a.statusBar, 1, 0, false).
+		AddItem(a.cmdline, 0, 0, false)
+	a.layout = mainFlex
+	for _, err := range a.startErrs {
+		a.logf("%s", tview.Escape(err.Error()))
+	}
+	a.loadFiles(currentDir)
 
 	a.fileList.SetSelectedFunc(func(index int, mainText, secondaryText string, shortcut rune) {
 		if files.IsDirectoryEntry(mainText) {
 			newDir := files.ResolveDirectory(a.currentDir, mainText)
 			if _, err := os.Stat(newDir); err == nil {
-				a.loadFiles(newDir)
+				a.changeDirectory(newDir)
 			}
 			return
 		}
 
-		selectedPath := filepath.Join(a.currentDir, mainText)
-		err := a.readMetadata(selectedPath)
-		if err != nil {
-			a.showError(err.Error())
-			return
-		}
-
-		a.originalMeta = &metadata.Metadata{
-			TrackName: a.meta.TrackName,
-			Artist:    a.meta.Artist,
-			Album:     a.meta.Album,
-			CoverPath: a.meta.CoverPath,
-		}
-
-		ui.PopulateForm(a.form, a.meta.TrackName, a.meta.Artist, a.meta.Album, a.meta.CoverPath)
-
-		a.focusIndex = 1
-		a.app.SetFocus(a.form.GetFormItem(0))
+		a.confirmDiscard(func() {
+			a.openFile(filepath.Join(a.currentDir, mainText))
+		})
 	})
 
 	mainFlex.SetInputCapture(ui.CreateInputCapture(false, ctx))
 
 	a.root = mainFlex
+	theme.Apply(a.root)
+	a.detectColors()
 	a.app.SetRoot(mainFlex, true)
 	a.app.SetFocus(a.fileList)
 
-	return a.app.Run()
+	err := a.app.Run()
+	if saveErr := a.index.Save(); err == nil && saveErr != nil {
+		err = fmt.Errorf("failed to save the tag index: %w", saveErr)
+	}
+	return err
 }
 
 func (a *App) runDirectEdit(filePath string) error {
@@ -193,46 +344,92 @@ func (a *App) runDirectEdit(filePath string) error {
 	a.currentFile = absPath
 	a.currentDir = filepath.Dir(absPath)
 
-	a.readMetadata(absPath)
-
-	a.originalMeta = &metadata.Metadata{
-		TrackName: a.meta.TrackName,
-		Artist:    a.meta.Artist,
-		Album:     a.meta.Album,
-		CoverPath: a.meta.CoverPath,
+	readErr := a.readMetadata(absPath)
+	if readErr != nil && !metadata.Overridable(readErr) {
+		return readErr
 	}
 
+	a.originalMeta = a.meta.Clone()
+
 	ctx := &ui.UIContext{
-		App:           a.app,
-		GetRoot:       a.getRoot,
-		ShowError:     a.showError,
-		ShowMessage:   a.showMessage,
-		GetForm:       a.getForm,
-		GetFileList:   a.getFileList,
-		GetCurrentDir: a.getCurrentDir,
-		SetCurrentDir: a.setCurrentDir,
-		GetFocusIndex: a.getFocusIndex,
-		SetFocusIndex: a.setFocusIndex,
-		SaveMetadata:  a.saveMetadata,
-		CurrentFile:   absPath,
+		App:             a.app,
+		GetRoot:         a.getRoot,
+		ShowError:       a.showError,
+		ShowMessage:     a.showMessage,
+		GetForm:         a.getForm,
+		GetFileList:     a.getFileList,
+		GetCurrentDir:   a.getCurrentDir,
+		SetCurrentDir:   a.setCurrentDir,
+		GetFocusIndex:   a.getFocusIndex,
+		SetFocusIndex:   a.setFocusIndex,
+		SaveMetadata:    a.saveMetadata,
+		TextActions:     a.textActions,
+		FixEncoding:     a.fixEncoding,
+		OpenInEditor:    a.openInEditor,
+		ExportCover:     a.exportCover,
+		ConvertVersion:  a.convertVersion,
+		StripTags:       a.stripTags,
+		ToggleLog:       a.toggleLog,
+		NextTheme:       a.nextTheme,
+		ShowHelp:        a.showHelp,
+		ShowPalette:     a.showPalette,
+		Quit:            a.quit,
+		FormChanged:     a.updateModified,
+		Keymap:          a.keymap,
+		Vim:             a.vim,
+		ModeChanged:     a.refreshStatus,
+		OpenCommandLine: a.openCommandLine,
+		FormFields:      a.formFields,
+		CurrentFile:     absPath,
+	}
+	if a.confirmSave {
+		ctx.ConfirmSave = a.confirmChanges
 	}
 
 	a.form = ui.CreateMetadataForm(true, ctx)
 
-	statusBar := ui.CreateStatusBar("Tab: Cycle fields | Enter: Save | Esc: Clear | q: Quit")
+	navigation := ""
+	if a.vim != nil {
+		navigation = "j/k: Fields | i: Insert | /: Search | :w :q"
+	}
+	a.statusBar = ui.CreateStatusBar("")
+	a.cmdline = ui.CreateCommandLine()
+	a.setStatus(a.statusText(navigation, keymap.ContextForm, []hint{
+		{"focus_next", "Cycle fields"},
+		{"save", "Save"},
+		{"text_actions", "Text actions"},
+		{"fix_encoding", "Fix encoding"},
+		{"edit_in_editor", "$EDITOR"},
+		{"toggle_log", "Log"},
+		{"next_theme", "Theme"},
+		{"clear_form", "Clear"},
+		{"command_palette", "Palette"},
+		{"help", "Help"},
+		{"quit", "Quit"},
+	}))
 	formWrapper := ui.CreateFormWrapper(a.form, "Editing: "+filepath.Base(absPath))
 
+	a.logPanel = ui.CreateLogPanel()
 	mainFlex := tview.NewFlex().SetDirection(tview.FlexRow).
 		AddItem(formWrapper, 0, 1, true).
-		AddItem(statusBar, 1, 0, false)
+		AddItem(a.logPanel, 0, 0, false).
+		AddItem(a.statusBar, 1, 0, false).
+		AddItem(a.cmdline, 0, 0, false)
+	a.layout = mainFlex
 
-	ui.PopulateForm(a.form, a.meta.TrackName, a.meta.Artist, a.meta.Album, a.meta.CoverPath)
+	ui.PopulateForm(a.form, a.meta)
 
 	mainFlex.SetInputCapture(ui.CreateInputCapture(true, ctx))
 
 	a.root = mainFlex
+	theme.Apply(a.root)
+	a.detectColors()
 	a.app.SetRoot(mainFlex, true)
 	a.app.SetFocus(a.form.GetFormItem(0))
+	a.setReadError(readErr)
+	if readErr != nil {
+		a.confirmOverwrite()
+	}
 
 	return a.app.Run()
 }
@@ -1,11 +1,19 @@
 package app
 
 import (
+	"errors"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/rivo/tview"
+
+	"id3v2-tui/internal/files"
 	"id3v2-tui/internal/metadata"
+	"id3v2-tui/internal/textops"
+	"id3v2-tui/internal/ui"
 )
 
 func TestIsMP3File(t *testing.T) {
@@ -140,9 +148,9 @@ func TestRunDirectEditWithInvalidPath(t *testing.T) {
 }
 
 func TestReadMetadata(t *testing.T) {
-	testFile := "../../test/test-w-metadata.mp3"
+	testFile := "../../test/test-w-metadata-v2.mp3"
 	if _, err := os.Stat(testFile); os.IsNotExist(err) {
-		t.Skip("test-w-metadata.mp3 not found")
+		t.Skip("test-w-metadata-v2.mp3 not found")
 	}
 
 	app := NewApp()
@@ -155,22 +163,58 @@ func TestReadMetadata(t *testing.T) {
 		t.Fatal("metadata is nil after readMetadata")
 	}
 
-	if app.meta.TrackName == "" && app.meta.Artist == "" && app.meta.Album == "" {
-		t.Log("Note: test file has no metadata")
+	if app.meta.TrackName != "aoba" {
+		t.Errorf("unexpected metadata %+v", app.meta)
 	}
 }
 
 func TestReadMetadataNonExistent(t *testing.T) {
 	app := NewApp()
 	err := app.readMetadata("/nonexistent/file.mp3")
-	if err != nil {
-		t.Errorf("readMetadata should not fail for non-existent file, got: %v", err)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("readMetadata should report a missing file, got: %v", err)
 	}
 	if app.meta == nil {
 		t.Error("metadata should be initialized even for non-existent file")
 	}
 }
 
+func TestSaveBlockedByReadError(t *testing.T) {
+	data, err := os.ReadFile("../../test/test-w-metadata.mp3")
+	if err != nil {
+		t.Skip("test-w-metadata.mp3 not found")
+	}
+	tmpFile := filepath.Join(t.TempDir(), "corrupt.mp3")
+	if err := os.WriteFile(tmpFile, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := NewApp()
+	app.form = tview.NewForm()
+	app.form.AddInputField(ui.FieldLabel("title"), "", 40, nil, nil)
+	err = app.readMetadata(tmpFile)
+	if !metadata.Overridable(err) {
+		t.Fatalf("expected an overridable read error, got %v", err)
+	}
+	app.currentFile = tmpFile
+	app.setReadError(err)
+	app.originalMeta = app.meta.Clone()
+
+	values := map[string]string{"title": "Fixed"}
+	if _, err := app.saveMetadata(tmpFile, values); !errors.Is(err, metadata.ErrCorruptHeader) {
+		t.Errorf("saving should be blocked, got %v", err)
+	}
+
+	app.overwrite = true
+	if _, err := app.saveMetadata(tmpFile, values); err != nil {
+		t.Fatalf("saving after the override failed: %v", err)
+	}
+	meta, err := metadata.Read(tmpFile)
+	if err != nil || meta.TrackName != "Fixed" {
+		t.Errorf("expected a readable tag after the override, got %+v, %v", meta, err)
+	}
+}
+
 func TestSaveMetadataAndRestore(t *testing.T) {
 	testFile := "../../test/test.mp3"
 	if _, err := os.Stat(testFile); os.IsNotExist(err) {
@@ -203,9 +247,10 @@ func TestSaveMetadataAndRestore(t *testing.T) {
 	}
 
 	app := NewApp()
+	app.currentFile = tmpFile
 	app.originalMeta = originalMeta
 
-	diff, err := app.saveMetadata(tmpFile, "Test Track", "Test Artist", "Test Album", "")
+	diff, err := app.saveMetadata(tmpFile, map[string]string{"title": "Test Track", "artist": "Test Artist", "album": "Test Album", "cover": ""})
 	if err != nil {
 		os.Remove(tmpFile)
 		t.Fatalf("saveMetadata failed: %v", err)
@@ -273,9 +318,10 @@ func TestSaveMetadataWithCoverAndRestore(t *testing.T) {
 	}
 
 	app := NewApp()
+	app.currentFile = tmpFile
 	app.originalMeta = originalMeta
 
-	_, err = app.saveMetadata(tmpFile, "Cover Test", "Cover Artist", "Cover Album", coverPath)
+	_, err = app.saveMetadata(tmpFile, map[string]string{"title": "Cover Test", "artist": "Cover Artist", "album": "Cover Album", "cover": coverPath})
 	if err != nil {
 		os.Remove(tmpFile)
 		t.Fatalf("saveMetadata with cover failed: %v", err)
@@ -304,3 +350,233 @@ func TestSaveMetadataWithCoverAndRestore(t *testing.T) {
 	}
 	os.Remove(tmpFile)
 }
+
+func TestSelectedPaths(t *testing.T) {
+	app := NewApp()
+	app.selected["/music/b.mp3"] = true
+	app.selected["/music/a.mp3"] = true
+
+	app.moveSelection("/music/b.mp3", "/music/c.mp3")
+
+	paths := app.selectedPaths()
+	if len(paths) != 2 || paths[0] != "/music/a.mp3" || paths[1] != "/music/c.mp3" {
+		t.Errorf("unexpected selection %v", paths)
+	}
+}
+
+func TestPendingChanges(t *testing.T) {
+	testFile := "../../test/test.mp3"
+	data, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Skip("test.mp3 not found")
+	}
+	tmpFile := filepath.Join(t.TempDir(), "test.mp3")
+	if err := os.WriteFile(tmpFile, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := NewApp()
+	app.form = tview.NewForm()
+	for _, name := range []string{"title", "artist"} {
+		app.form.AddInputField(ui.FieldLabel(name), "", 40, nil, func(string) { app.updateModified() })
+	}
+	if app.pendingChanges() != "" {
+		t.Error("no file is open, nothing can be modified")
+	}
+
+	app.currentFile = tmpFile
+	app.originalMeta = &metadata.Metadata{TrackName: "Title", Artist: "Artist"}
+	ui.PopulateForm(app.form, app.originalMeta)
+	if app.modified || app.pendingChanges() != "" {
+		t.Error("a freshly populated form should not be modified")
+	}
+
+	app.form.GetFormItemByLabel(ui.FieldLabel("title")).(*tview.InputField).SetText("New Title")
+	if !app.modified || !strings.Contains(app.pendingChanges(), "New Title") {
+		t.Errorf("expected the edit to be tracked, got %q", app.pendingChanges())
+	}
+	if app.form.GetTitle() != "Metadata Editor (modified)" {
+		t.Errorf("unexpected title %q", app.form.GetTitle())
+	}
+
+	if _, err := app.saveMetadata(tmpFile, ui.FormValues(app.form)); err != nil {
+		t.Fatalf("saveMetadata failed: %v", err)
+	}
+	if app.modified || app.pendingChanges() != "" {
+		t.Error("saving should reset the modified state")
+	}
+}
+
+func TestSaveWritesOpenFile(t *testing.T) {
+	data, err := os.ReadFile("../../test/test.mp3")
+	if err != nil {
+		t.Skip("test.mp3 not found")
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"a.mp3", "b.mp3"} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a, b := filepath.Join(dir, "a.mp3"), filepath.Join(dir, "b.mp3")
+	before, err := metadata.Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := NewApp()
+	app.app = tview.NewApplication()
+	app.currentDir = dir
+	app.fileList = tview.NewList()
+	app.fileList.AddItem("a.mp3", "", 0, nil).AddItem("b.mp3", "", 0, nil)
+	ctx := &ui.UIContext{
+		App:            app.app,
+		GetForm:        app.getForm,
+		GetFileList:    app.getFileList,
+		GetCurrentDir:  app.getCurrentDir,
+		GetCurrentFile: app.getCurrentFile,
+		SaveMetadata:   app.saveMetadata,
+		ShowError:      func(text string) { t.Errorf("unexpected error: %s", text) },
+		ShowMessage:    func(string) {},
+		FormFields:     []string{"title"},
+	}
+	app.form = ui.CreateMetadataForm(false, ctx)
+	ui.CreateInputCapture(false, ctx)
+
+	app.openFile(a)
+	app.fileList.SetCurrentItem(1)
+	app.form.GetFormItemByLabel(ui.FieldLabel("title")).(*tview.InputField).SetText("Only A")
+	ctx.Actions.Run("save")
+
+	if meta, _ := metadata.Read(a); meta.TrackName != "Only A" {
+		t.Errorf("expected the open file to be saved, got %q", meta.TrackName)
+	}
+	if after, _ := metadata.Read(b); after.Diff(before) != "" {
+		t.Errorf("the highlighted file must not change: %s", after.Diff(before))
+	}
+}
+
+func TestBatchKeepsMixedFields(t *testing.T) {
+	data, err := os.ReadFile("../../test/test.mp3")
+	if err != nil {
+		t.Skip("test.mp3 not found")
+	}
+	dir := t.TempDir()
+	var paths []string
+	for i, title := range []string{"One", "Two"} {
+		path := filepath.Join(dir, title+".mp3")
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := metadata.Save(path, &metadata.Metadata{TrackName: title, Artist: "Band", Year: []string{"1999", "2001"}[i]}); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	app := NewApp()
+	app.form = tview.NewForm()
+	for _, name := range []string{"title", "artist", "album", "year"} {
+		app.form.AddInputField(ui.FieldLabel(name), "", 40, nil, func(string) { app.updateModified() })
+	}
+	if err := app.loadBatch(paths); err != nil {
+		t.Fatal(err)
+	}
+	field := func(name string) *tview.InputField {
+		return app.form.GetFormItemByLabel(ui.FieldLabel(name)).(*tview.InputField)
+	}
+	if field("title").GetText() != "" || field("artist").GetText() != "Band" {
+		t.Errorf("mixed fields should be left empty, got %q", field("title").GetText())
+	}
+
+	field("album").SetText("<keep>")
+	field("year").SetText("x")
+	field("year").SetText("")
+	if diff := app.pendingChanges(); !strings.Contains(diff, "Year: (multiple values) → (removed)") || strings.Contains(diff, "Track") {
+		t.Errorf("unexpected pending changes %q", diff)
+	}
+
+	app.confirmSave = false
+	saved := false
+	if !app.saveBatch(ui.FormValues(app.form), func() { saved = true }) || !saved {
+		t.Fatal("expected the batch to be saved")
+	}
+	for i, path := range paths {
+		meta, err := metadata.Read(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if meta.TrackName != []string{"One", "Two"}[i] || meta.Album != "<keep>" || meta.Year != "" {
+			t.Errorf("unexpected metadata after batch save %+v", meta)
+		}
+	}
+}
+
+func TestTextActionOnlyTouchesVisibleFields(t *testing.T) {
+	app := NewApp()
+	app.app = tview.NewApplication()
+	app.form = tview.NewForm()
+	app.root = app.form
+	app.form.AddInputField(ui.FieldLabel("title"), "", 40, nil, nil)
+	app.currentFile = "song.mp3"
+	app.originalMeta = &metadata.Metadata{TrackName: "song", AlbumArtist: "band"}
+	ui.PopulateForm(app.form, app.originalMeta)
+
+	upper, _ := textops.Lookup("upper")
+	app.applyTextActionToForm(upper, metadata.Fields())
+	if diff := app.pendingChanges(); diff != "Track: song → SONG" {
+		t.Errorf("expected only the visible title to change, got %q", diff)
+	}
+}
+
+func TestDisplayOrderFollowsView(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for i, name := range []string{"a.mp3", "b.mp3", "c.mp3"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, make([]byte, []int{20, 30, 10}[i]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	app := NewApp()
+	app.view = files.View{Sort: "size", Descending: true}
+	if got := app.displayOrder(paths); strings.Join(got, ",") != strings.Join([]string{paths[1], paths[0], paths[2]}, ",") {
+		t.Errorf("expected the browser's size order, got %v", got)
+	}
+
+	app.view = files.DefaultView()
+	if got := app.displayOrder([]string{paths[2], paths[0], paths[1]}); strings.Join(got, ",") != strings.Join(paths, ",") {
+		t.Errorf("expected name order, got %v", got)
+	}
+}
+
+func TestEditorEntriesReadFromDisk(t *testing.T) {
+	data, err := os.ReadFile("../../test/test.mp3")
+	if err != nil {
+		t.Skip("test.mp3 not found")
+	}
+	path := filepath.Join(t.TempDir(), "test.mp3")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := metadata.Save(path, &metadata.Metadata{TrackName: "On disk"}); err != nil {
+		t.Fatal(err)
+	}
+
+	app := NewApp()
+	app.form = tview.NewForm()
+	app.form.AddInputField(ui.FieldLabel("title"), "", 40, nil, nil)
+	app.currentFile = path
+	app.originalMeta = &metadata.Metadata{TrackName: "On disk"}
+	app.form.GetFormItemByLabel(ui.FieldLabel("title")).(*tview.InputField).SetText("Unsaved")
+
+	entries, err := app.editorEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Meta.TrackName != "On disk" {
+		t.Errorf("expected the tags on disk, got %+v", entries)
+	}
+}
@@ -0,0 +1,216 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"id3v2-tui/internal/exchange"
+	"id3v2-tui/internal/hooks"
+	"id3v2-tui/internal/metadata"
+	"id3v2-tui/internal/modals"
+	"id3v2-tui/internal/ui"
+)
+
+func (a *App) editSelection() {
+	paths := a.selectedPaths()
+	if len(paths) == 0 {
+		a.showMessage("Select files first")
+		return
+	}
+	if err := a.loadBatch(paths); err != nil {
+		a.showError(err.Error())
+		return
+	}
+	a.focusIndex = 1
+	a.app.SetFocus(a.form.GetFormItem(0))
+}
+
+func (a *App) loadBatch(paths []string) error {
+	metas, err := readAll(paths, make(map[string]*metadata.Metadata))
+	if err != nil {
+		return err
+	}
+	a.batch = paths
+	a.currentFile = ""
+	a.setReadError(nil)
+	meta, mixed := metadata.Merge(metas)
+	a.meta = meta
+	a.originalMeta = a.meta.Clone()
+	ui.PopulateForm(a.form, a.meta)
+	a.keep = make(map[string]bool)
+	for _, name := range mixed {
+		a.keep[name] = true
+	}
+	ui.SetMixed(a.form, mixed)
+	a.refreshTitle()
+	return nil
+}
+
+func (a *App) releaseMixed() {
+	if len(a.keep) == 0 || a.form == nil {
+		return
+	}
+	values := ui.FormValues(a.form)
+	var kept []string
+	for _, name := range metadata.Fields() {
+		if !a.keep[name] {
+			continue
+		}
+		if values[name] != "" {
+			a.keep[name] = false
+			continue
+		}
+		kept = append(kept, name)
+	}
+	ui.SetMixed(a.form, kept)
+}
+
+func (a *App) saveBatch(values map[string]string, then func()) bool {
+	if len(a.batch) == 0 {
+		return false
+	}
+
+	var changes []*exchange.Change
+	var errs []string
+	for _, path := range a.batch {
+		before, err := metadata.Read(path)
+		if err != nil {
+			errs = append(errs, a.relPath(path)+": "+err.Error())
+			continue
+		}
+		after := before.Clone()
+		for name, value := range values {
+			switch {
+			case a.keep[name] && value == "":
+			case name == "cover":
+				after.CoverPath = value
+			default:
+				after.Set(name, value)
+			}
+		}
+		if before.Diff(after) != "" {
+			changes = append(changes, &exchange.Change{Path: path, Before: before, After: after})
+		}
+	}
+	if len(errs) > 0 {
+		a.showError(strings.Join(errs, "\n"))
+		return true
+	}
+	if len(changes) == 0 {
+		if then != nil {
+			then()
+		} else {
+			a.showMessage("No changes detected")
+		}
+		return true
+	}
+
+	apply := func() {
+		saved, errs := a.applyChanges(changes)
+		if err := a.loadBatch(a.batch); err != nil {
+			errs = append(errs, err.Error())
+		}
+		if then != nil && len(errs) == 0 {
+			then()
+			return
+		}
+		a.showBatchResult(saved, errs)
+	}
+	if !a.confirmSave {
+		apply()
+		return true
+	}
+
+	var rows []modals.DiffRow
+	for _, change := range changes {
+		for _, c := range change.Before.Changes(change.After) {
+			rows = append(rows, modals.DiffRow{Label: a.relPath(change.Path) + ": " + c.Label, Old: c.Old, New: c.New})
+		}
+	}
+	modals.ShowDiff(a.app, a.root, fmt.Sprintf("Save %d files", len(changes)), rows, apply)
+	return true
+}
+
+func readAll(paths []string, cache map[string]*metadata.Metadata) ([]*metadata.Metadata, error) {
+	metas := make([]*metadata.Metadata, len(paths))
+	var errs []string
+	for i, path := range paths {
+		meta, ok := cache[path]
+		if !ok {
+			var err error
+			meta, err = metadata.Read(path)
+			if err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			cache[path] = meta
+		}
+		metas[i] = meta
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("Tags of %d files could not be read:\n\n%s", len(errs), strings.Join(errs, "\n"))
+	}
+	return metas, nil
+}
+
+func (a *App) relPath(path string) string {
+	if rel, err := filepath.Rel(a.currentDir, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+func (a *App) saveAll(paths []string, metas []*metadata.Metadata) (int, []string) {
+	var errs []string
+	var changes []hooks.FileChange
+	for i, path := range paths {
+		before, err := metadata.Read(path)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if err := metadata.Save(path, metas[i]); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", a.relPath(path), err))
+			continue
+		}
+		changes = append(changes, hooks.FileChange{Path: path, Changes: hooks.Changes(before, metas[i])})
+	}
+	a.runHooks(hooks.EventBatch, changes)
+	return len(changes), errs
+}
+
+func (a *App) applyChanges(changes []*exchange.Change) (int, []string) {
+	saved, errs := exchange.Apply(changes)
+	failed := make(map[string]bool)
+	for _, err := range errs {
+		failed[err.Path] = true
+	}
+	var applied []hooks.FileChange
+	var paths []string
+	for _, change := range changes {
+		if !failed[change.Path] {
+			applied = append(applied, hooks.FileChange{Path: change.Path, Changes: hooks.Changes(change.Before, change.After)})
+			paths = append(paths, change.Path)
+		}
+	}
+	a.refreshLibrary(paths...)
+	a.runHooks(hooks.EventBatch, applied)
+	return saved, a.fileErrors(errs)
+}
+
+func (a *App) showBatchResult(saved int, errs []string) {
+	if len(errs) > 0 {
+		a.showError(fmt.Sprintf("Updated %d files, %d failed:\n\n%s", saved, len(errs), strings.Join(errs, "\n")))
+		return
+	}
+	a.showMessage(fmt.Sprintf("Updated %d files", saved))
+}
+
+func (a *App) fileErrors(errs []exchange.FileError) []string {
+	lines := make([]string, len(errs))
+	for i, err := range errs {
+		lines[i] = fmt.Sprintf("%s: %s", a.relPath(err.Path), err.Err)
+	}
+	return lines
+}
@@ -0,0 +1,336 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+
+	"id3v2-tui/internal/files"
+	"id3v2-tui/internal/library"
+	"id3v2-tui/internal/metadata"
+	"id3v2-tui/internal/modals"
+)
+
+func (a *App) loadFiles(dir string) {
+	a.reselect = ""
+	if dir == a.currentDir && a.fileList.GetItemCount() > 0 {
+		a.reselect, _ = a.fileList.GetItemText(a.fileList.GetCurrentItem())
+	} else {
+		a.filter = library.Query{}
+	}
+	a.currentDir = dir
+	a.view, _ = a.views.For(dir)
+	a.entries = make(map[string]library.Entry)
+	a.items = make(map[string]files.Item)
+	a.renderFiles()
+
+	ctx, t := a.startTask("files", "Listing")
+	hidden := a.view.Hidden
+	go func() {
+		var paths []string
+		err := files.Stream(ctx, dir, hidden, func(items []files.Item) {
+			for _, item := range items {
+				if !item.Dir {
+					paths = append(paths, filepath.Join(dir, item.Name))
+				}
+			}
+			a.update(ctx, func() {
+				for _, item := range items {
+					a.items[item.Name] = item
+				}
+				a.renderFiles()
+			})
+		})
+		if err != nil {
+			a.update(ctx, func() {
+				a.logf("%s", tview.Escape(err.Error()))
+				a.finishTask("files", t)
+			})
+			return
+		}
+
+		a.update(ctx, func() {
+			t.label = "Reading tags"
+			t.total = len(paths)
+			a.refreshStatus()
+		})
+		library.Stream(ctx, a.index, paths, library.Workers, 100*time.Millisecond, func(entries []library.Entry) {
+			a.update(ctx, func() {
+				for _, e := range entries {
+					a.entries[e.Path] = e
+				}
+				t.done += len(entries)
+				if a.filter.UsesTags() || metadata.IsField(a.view.Sort) {
+					a.renderFiles()
+				} else {
+					a.refreshMarks()
+				}
+				a.refreshStatus()
+			})
+		})
+		a.saveIndex(ctx, dir, false, paths)
+		a.update(ctx, func() { a.finishTask("files", t) })
+	}()
+}
+
+func (a *App) entry(item files.Item) (library.Entry, bool) {
+	e, ok := a.entries[filepath.Join(a.currentDir, item.Name)]
+	return e, ok
+}
+
+func (a *App) visible(item files.Item) bool {
+	if a.filter.Empty() {
+		return true
+	}
+	if e, ok := a.entry(item); ok {
+		return a.filter.Match(item.Name, &e)
+	}
+	return a.filter.Match(item.Name, nil)
+}
+
+func (a *App) compareTags(x, y files.Item) int {
+	ex, okX := a.entry(x)
+	ey, okY := a.entry(y)
+	if !okX {
+		ex.Meta = &metadata.Metadata{}
+	}
+	if !okY {
+		ey.Meta = &metadata.Metadata{}
+	}
+	return library.Compare(ex, ey, a.view.Sort)
+}
+
+func (a *App) renderFiles() {
+	current := a.reselect
+	if current == "" && a.fileList.GetItemCount() > 0 {
+		current, _ = a.fileList.GetItemText(a.fileList.GetCurrentItem())
+	}
+
+	shown := make([]files.Item, 0, len(a.items))
+	for _, item := range a.items {
+		if a.visible(item) {
+			shown = append(shown, item)
+		}
+	}
+	a.view.SortItems(shown, a.compareTags)
+
+	files.Reset(a.fileList, a.currentDir)
+	for _, item := range shown {
+		index := files.Add(a.fileList, item)
+		if item.Text() == current {
+			a.fileList.SetCurrentItem(index)
+			a.reselect = ""
+		}
+	}
+	a.refreshMarks()
+
+	title := "Files - " + filepath.Base(a.currentDir)
+	if a.view.Sort != "name" || a.view.Descending {
+		arrow := "▲"
+		if a.view.Descending {
+			arrow = "▼"
+		}
+		title += fmt.Sprintf(" [%s %s]", a.view.Sort, arrow)
+	}
+	if !a.filter.Empty() {
+		title += fmt.Sprintf(" (%d of %d, /%s)", len(shown), len(a.items), a.filter)
+	}
+	a.fileList.SetTitle(tview.Escape(title))
+}
+
+func (a *App) refreshMarks() {
+	for i := 0; i < a.fileList.GetItemCount(); i++ {
+		a.renderItem(i)
+	}
+}
+
+func (a *App) renderItem(index int) {
+	mainText, _ := a.fileList.GetItemText(index)
+	item := a.items[mainText]
+	info := a.view.Info(item)
+	if e, ok := a.entry(item); ok {
+		if summary := e.Summary(); summary != "" {
+			info = append(info, summary)
+		}
+	}
+	path := filepath.Join(a.currentDir, mainText)
+	files.SetInfo(a.fileList, index, a.selected[path], strings.Join(info, " · "))
+}
+
+func (a *App) filterBrowser() {
+	previous := a.filter
+	if a.libraryShown() {
+		previous = a.library.Query
+	}
+	apply := func(q library.Query) {
+		if a.libraryShown() {
+			a.library.SetQuery(q)
+			return
+		}
+		a.filter = q
+		a.renderFiles()
+	}
+	a.openPrompt("/", previous.String(), func(text string) {
+		apply(library.ParseQuery(text))
+	}, func(text string, submitted bool) {
+		if !submitted {
+			apply(previous)
+		}
+	})
+}
+
+func (a *App) sortBrowser() {
+	if a.libraryShown() {
+		a.sortLibrary()
+		return
+	}
+
+	keys := append(append([]string(nil), files.SortKeys...), metadata.Fields()...)
+	sortBy := 0
+	for i, key := range keys {
+		if key == a.view.Sort {
+			sortBy = i
+		}
+	}
+	columns := [][]string{nil, {"size"}, {"mtime"}, {"size", "mtime"}}
+	shown := 0
+	for i, c := range columns {
+		if strings.Join(c, ",") == strings.Join(a.view.Columns, ",") {
+			shown = i
+		}
+	}
+	_, own := a.views.For(a.currentDir)
+	scope := 1
+	if own {
+		scope = 0
+	}
+
+	choices := []modals.Choice{
+		{Label: "Sort by", Options: keys, Selected: sortBy},
+		{Label: "Order", Options: []string{"Ascending", "Descending"}, Selected: boolIndex(a.view.Descending)},
+		{Label: "Hidden files", Options: []string{"Hide", "Show"}, Selected: boolIndex(a.view.Hidden)},
+		{Label: "Columns", Options: []string{"None", "Size", "Modified", "Size and modified"}, Selected: shown},
+		{Label: "Remember for", Options: []string{"This directory", "All directories", "Reset to configuration"}, Selected: scope},
+	}
+	modals.ShowChoices(a.app, a.root, "View options", choices, func(selected []int) {
+		if selected[4] == 2 {
+			a.resetView()
+			return
+		}
+		a.setView(files.View{
+			Sort:       keys[selected[0]],
+			Descending: selected[1] == 1,
+			Hidden:     selected[2] == 1,
+			Columns:    columns[selected[3]],
+		}, selected[4] == 0)
+	})
+}
+
+func boolIndex(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (a *App) toggleHidden() {
+	v := a.view
+	v.Hidden = !v.Hidden
+	_, own := a.views.For(a.currentDir)
+	a.setView(v, own)
+}
+
+func (a *App) setView(v files.View, perDir bool) {
+	var err error
+	if perDir {
+		err = a.views.Set(a.currentDir, v)
+	} else if err = a.views.Set("", v); err == nil {
+		err = a.views.Forget(a.currentDir)
+	}
+	if err != nil {
+		a.showError("Could not save view options: " + err.Error())
+	}
+	a.applyView(v)
+}
+
+func (a *App) resetView() {
+	if err := a.views.Reset(a.currentDir); err != nil {
+		a.showError("Could not save view options: " + err.Error())
+	}
+	v, _ := a.views.For(a.currentDir)
+	a.applyView(v)
+}
+
+func (a *App) applyView(v files.View) {
+	reload := v.Hidden != a.view.Hidden
+	a.view = v
+	if reload {
+		a.loadFiles(a.currentDir)
+		return
+	}
+	a.renderFiles()
+}
+
+func (a *App) saveIndex(ctx context.Context, dir string, recursive bool, paths []string) {
+	if ctx.Err() != nil {
+		return
+	}
+	a.index.Prune(dir, recursive, paths)
+	if err := a.index.Save(); err != nil {
+		a.update(ctx, func() { a.logf("index: %s", tview.Escape(err.Error())) })
+	}
+}
+
+func (a *App) displayOrder(paths []string) []string {
+	ordered := append([]string(nil), paths...)
+	if a.libraryShown() {
+		rank := make(map[string]int, len(a.library.Entries))
+		for i, e := range a.library.Entries {
+			rank[e.Path] = i
+		}
+		sort.SliceStable(ordered, func(i, j int) bool {
+			ri, okI := rank[ordered[i]]
+			rj, okJ := rank[ordered[j]]
+			if okI != okJ {
+				return okI
+			}
+			return ri < rj
+		})
+		return ordered
+	}
+
+	items := make([]files.Item, 0, len(ordered))
+	entries := make(map[string]library.Entry, len(ordered))
+	for _, path := range ordered {
+		item := files.Item{Name: path}
+		if info, err := os.Stat(path); err == nil {
+			item.Size, item.ModTime = info.Size(), info.ModTime()
+		}
+		if e, ok := a.entries[path]; ok {
+			entries[path] = e
+		} else if metadata.IsField(a.view.Sort) {
+			entries[path] = a.index.Load(path)
+		}
+		items = append(items, item)
+	}
+	a.view.SortItems(items, func(x, y files.Item) int {
+		ex, ey := entries[x.Name], entries[y.Name]
+		if ex.Meta == nil {
+			ex.Meta = &metadata.Metadata{}
+		}
+		if ey.Meta == nil {
+			ey.Meta = &metadata.Metadata{}
+		}
+		return library.Compare(ex, ey, a.view.Sort)
+	})
+	for i, item := range items {
+		ordered[i] = item.Name
+	}
+	return ordered
+}
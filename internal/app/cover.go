@@ -0,0 +1,33 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"id3v2-tui/internal/metadata"
+	"id3v2-tui/internal/modals"
+)
+
+func (a *App) exportCover() {
+	path := a.currentFile
+	if path == "" {
+		a.showMessage("Open a file first")
+		return
+	}
+
+	data, mimeType, err := metadata.ReadCover(path)
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+
+	target := filepath.Join(filepath.Dir(path), "cover"+metadata.PictureExtension(mimeType))
+	modals.ShowInput(a.app, a.root, "Export cover", "Save to", target, func(target string) {
+		if err := os.WriteFile(target, data, 0o644); err != nil {
+			a.showError(err.Error())
+			return
+		}
+		a.showMessage(fmt.Sprintf("Cover saved to %s (%d bytes)", target, len(data)))
+	})
+}
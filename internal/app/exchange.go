@@ -0,0 +1,178 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"id3v2-tui/internal/commands"
+	"id3v2-tui/internal/exchange"
+	"id3v2-tui/internal/metadata"
+	"id3v2-tui/internal/modals"
+	"id3v2-tui/internal/ui"
+)
+
+func (a *App) exportCSV() {
+	paths := a.selectedPaths()
+	if len(paths) == 0 {
+		a.showMessage("No files selected. Press Space in the file browser to select files.")
+		return
+	}
+
+	modals.ShowInput(a.app, a.root, "Export CSV", "File", filepath.Join(a.currentDir, "tags.csv"), func(output string) {
+		f, err := os.Create(output)
+		if err != nil {
+			a.showError(err.Error())
+			return
+		}
+		errs := exchange.WriteCSV(f, paths, exchange.Delimiter(output))
+		if err := f.Close(); err != nil {
+			errs = append(errs, exchange.FileError{Path: output, Err: err})
+		}
+
+		if len(errs) > 0 {
+			a.showError("Export finished with errors:\n\n" + strings.Join(a.fileErrors(errs), "\n"))
+			return
+		}
+		a.showMessage(fmt.Sprintf("Exported %d files to %s", len(paths), output))
+	})
+}
+
+func (a *App) importCSV() {
+	modals.ShowInput(a.app, a.root, "Import CSV", "File", filepath.Join(a.currentDir, "tags.csv"), func(input string) {
+		f, err := os.Open(input)
+		if err != nil {
+			a.showError(err.Error())
+			return
+		}
+		changes, errs, err := exchange.PlanCSV(f, exchange.Delimiter(input))
+		f.Close()
+		if err != nil {
+			a.showError(err.Error())
+			return
+		}
+		if len(changes) == 0 && len(errs) == 0 {
+			a.showMessage("No changes detected")
+			return
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "%d files will change\n", len(changes))
+		for _, change := range changes {
+			fmt.Fprintf(&b, "\n%s\n  %s\n", a.relPath(change.Path), strings.ReplaceAll(change.Diff(), "\n", "\n  "))
+		}
+		if len(errs) > 0 {
+			fmt.Fprintf(&b, "\nProblems (these rows will be skipped):\n  %s\n", strings.Join(a.fileErrors(errs), "\n  "))
+		}
+
+		modals.ShowPreview(a.app, a.root, "Import CSV", b.String(), func() {
+			a.showBatchResult(a.applyChanges(changes))
+		})
+	})
+}
+
+func (a *App) editorEntries() ([]exchange.TextEntry, error) {
+	if paths := a.selectedPaths(); len(paths) > 0 {
+		metas, err := readAll(paths, make(map[string]*metadata.Metadata))
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]exchange.TextEntry, len(paths))
+		for i, path := range paths {
+			entries[i] = exchange.TextEntry{Path: path, Meta: metas[i]}
+		}
+		return entries, nil
+	}
+	if a.currentFile != "" {
+		meta, err := metadata.Read(a.currentFile)
+		if err != nil {
+			return nil, err
+		}
+		return []exchange.TextEntry{{Path: a.currentFile, Meta: meta}}, nil
+	}
+	return nil, nil
+}
+
+func (a *App) openInEditor() {
+	a.confirmDiscard(a.editInEditor)
+}
+
+func (a *App) editInEditor() {
+	entries, err := a.editorEntries()
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	if len(entries) == 0 {
+		a.showMessage("Open or select a file first")
+		return
+	}
+
+	tmp, err := os.CreateTemp("", "id3v2-tui-*.txt")
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	err = exchange.WriteText(tmp, entries)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+
+	name, args := commands.EditorCommand()
+	var runErr error
+	a.app.Suspend(func() {
+		runErr = a.executor.RunInteractive(name, append(args, tmp.Name())...)
+	})
+	if runErr != nil {
+		a.showError("Editor failed: " + runErr.Error())
+		return
+	}
+
+	f, err := os.Open(tmp.Name())
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	edited, err := exchange.ReadText(f)
+	f.Close()
+	if err != nil {
+		a.showError("Could not parse edited tags: " + err.Error())
+		return
+	}
+
+	changes, err := exchange.PlanText(entries, edited)
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	if len(changes) == 0 {
+		a.showMessage("No changes detected")
+		return
+	}
+
+	var b strings.Builder
+	for _, change := range changes {
+		fmt.Fprintf(&b, "%s\n  %s\n\n", a.relPath(change.Path), strings.ReplaceAll(change.Diff(), "\n", "\n  "))
+	}
+	modals.ShowPreview(a.app, a.root, "Apply edited tags", b.String(), func() {
+		saved, errs := a.applyChanges(changes)
+		for _, change := range changes {
+			if change.Path != a.currentFile {
+				continue
+			}
+			if meta, err := metadata.Read(change.Path); err == nil {
+				a.meta = meta
+				a.originalMeta = meta.Clone()
+				ui.PopulateForm(a.form, a.meta)
+			}
+		}
+		a.showBatchResult(saved, errs)
+	})
+}
@@ -0,0 +1,189 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"id3v2-tui/internal/hooks"
+	"id3v2-tui/internal/metadata"
+	"id3v2-tui/internal/modals"
+	"id3v2-tui/internal/ui"
+)
+
+func (a *App) readMetadata(filePath string) error {
+	meta, err := metadata.Read(filePath)
+	if err != nil {
+		a.meta = &metadata.Metadata{}
+		return err
+	}
+	a.meta = meta
+	return nil
+}
+
+func (a *App) formMetadata(values map[string]string) *metadata.Metadata {
+	meta := a.originalMeta.Clone()
+	for name, value := range values {
+		if name == "cover" {
+			meta.CoverPath = value
+		} else {
+			meta.Set(name, value)
+		}
+	}
+	return meta
+}
+
+func (a *App) saveMetadata(filePath string, values map[string]string) (string, error) {
+	newMeta := a.formMetadata(values)
+
+	diff := a.originalMeta.Diff(newMeta)
+
+	if filePath == "" || filePath != a.currentFile {
+		return "", fmt.Errorf("%s is not the open file", filepath.Base(filePath))
+	}
+	save := metadata.Save
+	if a.readErr != nil {
+		if !a.overwrite {
+			return "", fmt.Errorf("%w\n\nReopen the file and choose \"Edit anyway\" to replace its tag", a.readErr)
+		}
+		save = metadata.Overwrite
+	}
+	if err := save(filePath, newMeta); err != nil {
+		return "", err
+	}
+
+	a.meta = newMeta
+	a.refreshLibrary(filePath)
+	a.runHooks(hooks.EventSave, []hooks.FileChange{{Path: filePath, Changes: hooks.Changes(a.originalMeta, newMeta)}})
+	a.originalMeta = newMeta.Clone()
+	a.updateModified()
+	return diff, nil
+}
+
+func (a *App) confirmChanges(filePath string, values map[string]string, apply func()) {
+	changes, err := metadata.PlanSave(filePath, a.formMetadata(values))
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	if len(changes) == 0 {
+		apply()
+		return
+	}
+
+	rows := make([]modals.DiffRow, len(changes))
+	for i, c := range changes {
+		rows[i] = modals.DiffRow(c)
+	}
+	modals.ShowDiff(a.app, a.root, "Save "+filepath.Base(filePath), rows, apply)
+}
+
+func (a *App) refreshTitle() {
+	title := "Metadata Editor"
+	if len(a.batch) > 0 {
+		title = fmt.Sprintf("Editing %d files", len(a.batch))
+	}
+	if a.readErr != nil && !a.overwrite {
+		title += " (read-only)"
+	}
+	if a.modified {
+		title += " (modified)"
+	}
+	a.form.SetTitle(title)
+}
+
+func (a *App) pendingChanges() string {
+	if (a.currentFile == "" && len(a.batch) == 0) || a.form == nil {
+		return ""
+	}
+	values := ui.FormValues(a.form)
+	changes := []string{a.originalMeta.Diff(a.formMetadata(values))}
+	for _, name := range metadata.Fields() {
+		if kept, mixed := a.keep[name]; mixed && !kept && values[name] == "" {
+			changes = append(changes, ui.FieldLabel(name)+": (multiple values) → (removed)")
+		}
+	}
+	return strings.TrimSpace(strings.Join(changes, "\n"))
+}
+
+func (a *App) updateModified() {
+	a.releaseMixed()
+	modified := a.pendingChanges() != ""
+	if a.form == nil || modified == a.modified {
+		return
+	}
+	a.modified = modified
+	a.refreshTitle()
+	if a.statusBar != nil {
+		a.refreshStatus()
+	}
+}
+
+func (a *App) confirmDiscard(next func()) {
+	diff := a.pendingChanges()
+	if diff == "" {
+		next()
+		return
+	}
+
+	name := filepath.Base(a.currentFile)
+	if len(a.batch) > 0 {
+		name = fmt.Sprintf("%d files", len(a.batch))
+	}
+	text := "Unsaved changes in " + name + ":\n\n" + diff
+	modals.ShowUnsaved(a.app, a.root, text, func() {
+		if a.saveBatch(ui.FormValues(a.form), next) {
+			return
+		}
+		if _, err := a.saveMetadata(a.currentFile, ui.FormValues(a.form)); err != nil {
+			a.showError(err.Error())
+			return
+		}
+		next()
+	}, func() {
+		ui.PopulateForm(a.form, a.originalMeta)
+		next()
+	})
+}
+
+func (a *App) openFile(selectedPath string) {
+	err := a.readMetadata(selectedPath)
+	if err != nil && !metadata.Overridable(err) {
+		a.showError(err.Error())
+		return
+	}
+	a.currentFile = selectedPath
+	a.batch = nil
+	a.keep = nil
+	ui.SetMixed(a.form, nil)
+	a.setReadError(err)
+
+	a.originalMeta = a.meta.Clone()
+
+	ui.PopulateForm(a.form, a.meta)
+
+	if err != nil {
+		a.confirmOverwrite()
+		return
+	}
+	a.focusIndex = 1
+	a.app.SetFocus(a.form.GetFormItem(0))
+}
+
+func (a *App) setReadError(err error) {
+	a.readErr = err
+	a.overwrite = false
+	ui.SetFormDisabled(a.form, err != nil)
+	a.refreshTitle()
+}
+
+func (a *App) confirmOverwrite() {
+	text := fmt.Sprintf("The tags could not be read:\n\n%s\n\nEditing is blocked because saving replaces the whole tag and drops any frames that could not be parsed.", a.readErr)
+	modals.ShowConfirm(a.app, a.root, text, "Edit anyway", func() {
+		a.overwrite = true
+		ui.SetFormDisabled(a.form, false)
+		a.refreshTitle()
+		a.focusIndex = 1
+		a.app.SetFocus(a.form.GetFormItem(0))
+	})
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+
+	"id3v2-tui/internal/hooks"
+	"id3v2-tui/internal/rename"
+)
+
+func (a *App) logHookResult(event hooks.Event, result hooks.Result) {
+	status := "[green]ok[-]"
+	if result.Err != nil {
+		status = "[red]failed: " + tview.Escape(result.Err.Error()) + "[-]"
+	}
+	a.logf("%s hook %s %s", event, tview.Escape(result.Command), status)
+	if output := strings.TrimRight(result.Output, "\n"); output != "" {
+		fmt.Fprintf(a.logPanel, "  %s\n", strings.ReplaceAll(tview.Escape(output), "\n", "\n  "))
+	}
+	a.logPanel.ScrollToEnd()
+	if result.Err != nil && !a.logVisible {
+		a.showLog(true)
+	}
+}
+
+func (a *App) runHooks(event hooks.Event, changes []hooks.FileChange) {
+	if len(a.hooks[event]) == 0 || len(changes) == 0 {
+		return
+	}
+
+	payload := hooks.Payload{Event: event, Files: changes}
+	go a.hooks.Run(a.executor, payload, func(result hooks.Result) {
+		a.app.QueueUpdateDraw(func() {
+			a.logHookResult(event, result)
+		})
+	})
+}
+
+func (a *App) renameHooks(moves []rename.Move, undo bool) {
+	changes := make([]hooks.FileChange, len(moves))
+	for i, m := range moves {
+		if undo {
+			changes[i] = hooks.FileChange{Path: m.From, From: m.To}
+		} else {
+			changes[i] = hooks.FileChange{Path: m.To, From: m.From}
+		}
+	}
+	a.runHooks(hooks.EventRename, changes)
+}
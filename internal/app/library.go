@@ -0,0 +1,144 @@
+package app
+
+import (
+	"time"
+
+	"github.com/rivo/tview"
+
+	"id3v2-tui/internal/files"
+	"id3v2-tui/internal/library"
+	"id3v2-tui/internal/modals"
+	"id3v2-tui/internal/theme"
+	"id3v2-tui/internal/ui"
+)
+
+func (a *App) createLibrary() {
+	a.library = ui.CreateLibraryTable()
+	a.library.Marked = func(path string) bool { return a.selected[path] }
+	a.library.SetSelectedFunc(func(row, column int) {
+		path := a.library.SelectedPath()
+		if path == "" {
+			return
+		}
+		a.confirmDiscard(func() {
+			a.openFile(path)
+		})
+	})
+}
+
+func (a *App) libraryShown() bool {
+	return a.browser != nil && a.browser.GetItem(0) == a.library
+}
+
+func (a *App) getBrowser() tview.Primitive {
+	if a.libraryShown() {
+		return a.library
+	}
+	return a.fileList
+}
+
+func (a *App) showBrowser(p tview.Primitive) {
+	width := 1
+	if p == a.library {
+		width = 3
+	}
+	a.browser.Clear().
+		AddItem(p, 0, width, true).
+		AddItem(a.form, 0, 2, false)
+	theme.Apply(p)
+	a.focusIndex = 0
+	a.app.SetFocus(p)
+}
+
+func (a *App) toggleLibrary() {
+	if a.libraryShown() {
+		a.stopTask("library")
+		a.refreshMarks()
+		a.showBrowser(a.fileList)
+		return
+	}
+	a.showBrowser(a.library)
+	a.scanLibrary()
+}
+
+func (a *App) scanLibrary() {
+	dir := a.currentDir
+	a.library.Reset(dir)
+
+	ctx, t := a.startTask("library", "Scanning library")
+	go func() {
+		paths, err := files.ListAudioFiles(dir, true)
+		if err != nil {
+			a.update(ctx, func() {
+				a.finishTask("library", t)
+				a.showError(err.Error())
+			})
+			return
+		}
+
+		a.update(ctx, func() {
+			t.total = len(paths)
+			a.refreshStatus()
+		})
+		library.Stream(ctx, a.index, paths, library.Workers, 100*time.Millisecond, func(entries []library.Entry) {
+			a.update(ctx, func() {
+				t.done += len(entries)
+				a.library.Add(entries...)
+				a.refreshStatus()
+			})
+		})
+		a.saveIndex(ctx, dir, true, paths)
+		a.update(ctx, func() {
+			a.library.SortBy(a.library.Column, a.library.Descending)
+			a.finishTask("library", t)
+		})
+	}()
+}
+
+func (a *App) refreshLibrary(paths ...string) {
+	scanned := a.library != nil && len(a.library.Entries) > 0
+	var entries []library.Entry
+	for _, path := range paths {
+		_, listed := a.entries[path]
+		if !listed && !scanned {
+			continue
+		}
+		e := a.index.Load(path)
+		if listed {
+			a.entries[path] = e
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) == 0 {
+		return
+	}
+	if scanned {
+		a.library.Update(entries...)
+	}
+	a.refreshMarks()
+}
+
+func (a *App) sortLibrary() {
+	if !a.libraryShown() {
+		a.showMessage("Switch to the library view first")
+		return
+	}
+
+	column := 0
+	for i, c := range library.Columns {
+		if c == a.library.Column {
+			column = i
+		}
+	}
+	order := 0
+	if a.library.Descending {
+		order = 1
+	}
+	choices := []modals.Choice{
+		{Label: "Sort by", Options: library.Columns, Selected: column},
+		{Label: "Order", Options: []string{"Ascending", "Descending"}, Selected: order},
+	}
+	modals.ShowChoices(a.app, a.root, "Sort library", choices, func(selected []int) {
+		a.library.SortBy(library.Columns[selected[0]], selected[1] == 1)
+	})
+}
@@ -0,0 +1,27 @@
+package app
+
+import (
+	"id3v2-tui/internal/actions"
+	"id3v2-tui/internal/keymap"
+	"id3v2-tui/internal/modals"
+)
+
+func (a *App) showPalette(context keymap.Context, registry *actions.Registry) {
+	search := func(query string) []modals.PaletteItem {
+		var items []modals.PaletteItem
+		for _, name := range registry.Search(query) {
+			if name == "command_palette" {
+				continue
+			}
+			items = append(items, modals.PaletteItem{
+				Name:        name,
+				Description: actions.Description(name),
+				Key:         a.keymap.Hint(name, context, keymap.ContextGlobal),
+			})
+		}
+		return items
+	}
+	modals.ShowPalette(a.app, a.root, search, func(name string) {
+		registry.Run(name)
+	})
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"id3v2-tui/internal/files"
+	"id3v2-tui/internal/metadata"
+	"id3v2-tui/internal/modals"
+	"id3v2-tui/internal/rename"
+)
+
+func (a *App) renameFromTags() {
+	modals.ShowInput(a.app, a.root, "Rename from tags", "Template", a.renameTemplate, func(template string) {
+		a.renameTemplate = template
+		a.previewRename(template)
+	})
+}
+
+func (a *App) previewRename(template string) {
+	sources, err := files.ListAudioFiles(a.currentDir, false)
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+
+	metas, err := readAll(sources, make(map[string]*metadata.Metadata))
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+
+	plan, err := rename.NewPlan(a.currentDir, template, sources, metas)
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	if len(plan.Collisions) > 0 {
+		a.showError("Rename aborted, name collisions:\n\n" + strings.Join(plan.Collisions, "\n"))
+		return
+	}
+	if len(plan.Moves) == 0 {
+		a.showMessage(plan.Preview())
+		return
+	}
+
+	modals.ShowPreview(a.app, a.root, "Rename from tags", plan.Preview(), func() {
+		a.applyRename(plan)
+	})
+}
+
+func (a *App) applyRename(plan *rename.Plan) {
+	applied, created, err := rename.Apply(plan.Moves)
+	if len(applied) > 0 {
+		a.renameHistory = append(a.renameHistory, rename.Plan{Base: plan.Base, Moves: applied, Created: created})
+	}
+	for _, m := range applied {
+		a.moveSelection(m.From, m.To)
+	}
+	a.loadFiles(a.currentDir)
+	a.renameHooks(applied, false)
+
+	if err != nil {
+		a.showError(fmt.Sprintf("Renamed %d of %d files: %s", len(applied), len(plan.Moves), err))
+		return
+	}
+	a.showMessage(fmt.Sprintf("Renamed %d files", len(applied)))
+}
+
+func (a *App) undoRename() {
+	if len(a.renameHistory) == 0 {
+		a.showMessage("Nothing to undo")
+		return
+	}
+
+	last := a.renameHistory[len(a.renameHistory)-1]
+	a.renameHistory = a.renameHistory[:len(a.renameHistory)-1]
+
+	err := rename.Undo(last.Moves, last.Created)
+	for _, m := range last.Moves {
+		a.moveSelection(m.To, m.From)
+	}
+	a.loadFiles(a.currentDir)
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	a.renameHooks(last.Moves, true)
+	a.showMessage(fmt.Sprintf("Restored %d files", len(last.Moves)))
+}
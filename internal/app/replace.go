@@ -0,0 +1,89 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"id3v2-tui/internal/metadata"
+	"id3v2-tui/internal/modals"
+	"id3v2-tui/internal/replace"
+)
+
+func (a *App) findReplace() {
+	cache := make(map[string]*metadata.Metadata)
+
+	prepare := func(req modals.FindReplaceRequest) ([]*replace.Change, int, error) {
+		fields, err := metadata.ParseFields(req.Fields)
+		if err != nil {
+			return nil, 0, err
+		}
+		rule, err := replace.NewRule(req.Pattern, req.Replacement, fields)
+		if err != nil {
+			return nil, 0, err
+		}
+		paths, err := a.batchPaths(req.Recursive)
+		if err != nil {
+			return nil, 0, err
+		}
+		metas, err := readAll(paths, cache)
+		if err != nil {
+			return nil, 0, err
+		}
+		return replace.Preview(rule, paths, metas), len(paths), nil
+	}
+
+	preview := func(req modals.FindReplaceRequest) string {
+		if req.Pattern == "" {
+			return "Enter a Go regular expression. Use $1 or ${name} in the replacement for capture groups.\nLeave Fields empty to search every field."
+		}
+		changes, total, err := prepare(req)
+		if err != nil {
+			return "Error: " + err.Error()
+		}
+		if total == 0 {
+			return "No files selected. Press Space in the file browser to select files, or use the recursive scope."
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "%d of %d files will change\n", len(changes), total)
+		for _, change := range changes {
+			fmt.Fprintf(&b, "\n%s\n  %s\n", a.relPath(change.Path), strings.ReplaceAll(change.Diff(), "\n", "\n  "))
+		}
+		return b.String()
+	}
+
+	review := func(req modals.FindReplaceRequest) {
+		changes, _, err := prepare(req)
+		if err != nil {
+			a.showError(err.Error())
+			return
+		}
+		if len(changes) == 0 {
+			a.showMessage("No changes detected")
+			return
+		}
+
+		items := make([]modals.ChecklistItem, len(changes))
+		for i, change := range changes {
+			items[i] = modals.ChecklistItem{
+				Label:   a.relPath(change.Path),
+				Detail:  strings.ReplaceAll(change.Diff(), "\n", "; "),
+				Checked: change.Accept,
+			}
+		}
+
+		modals.ShowChecklist(a.app, a.root, "Find and replace", items, func(items []modals.ChecklistItem) {
+			var paths []string
+			var metas []*metadata.Metadata
+			for i, item := range items {
+				if item.Checked {
+					paths = append(paths, changes[i].Path)
+					metas = append(metas, changes[i].After)
+				}
+			}
+			a.showBatchResult(a.saveAll(paths, metas))
+		})
+	}
+
+	modals.ShowFindReplace(a.app, a.root, len(a.selected) == 0, preview, review)
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"sort"
+
+	"id3v2-tui/internal/files"
+)
+
+func (a *App) toggleSelection() {
+	if a.libraryShown() {
+		path := a.library.SelectedPath()
+		if path == "" {
+			return
+		}
+		if a.selected[path] {
+			delete(a.selected, path)
+		} else {
+			a.selected[path] = true
+		}
+		a.library.Refresh()
+		a.library.Move(1)
+		return
+	}
+
+	index := a.fileList.GetCurrentItem()
+	path := files.GetSelectedPath(a.fileList, a.currentDir)
+	if path == "" {
+		return
+	}
+
+	if a.selected[path] {
+		delete(a.selected, path)
+	} else {
+		a.selected[path] = true
+	}
+	a.renderItem(index)
+
+	if index+1 < a.fileList.GetItemCount() {
+		a.fileList.SetCurrentItem(index + 1)
+	}
+}
+
+func (a *App) moveSelection(from, to string) {
+	if a.selected[from] {
+		delete(a.selected, from)
+		a.selected[to] = true
+	}
+}
+
+func (a *App) selectedPaths() []string {
+	paths := make([]string, 0, len(a.selected))
+	for path := range a.selected {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (a *App) batchPaths(recursive bool) ([]string, error) {
+	if recursive {
+		return files.ListAudioFiles(a.currentDir, true)
+	}
+	return a.selectedPaths(), nil
+}
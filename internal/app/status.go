@@ -0,0 +1,64 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"id3v2-tui/internal/keymap"
+)
+
+type hint struct {
+	action string
+	label  string
+}
+
+func (a *App) statusText(prefix string, context keymap.Context, hints []hint) string {
+	parts := []string{}
+	if prefix != "" {
+		parts = append(parts, prefix)
+	}
+	for _, h := range hints {
+		if key := a.keymap.Hint(h.action, context, keymap.ContextGlobal); key != "" {
+			parts = append(parts, key+": "+h.label)
+		}
+	}
+	return strings.Join(parts, " | ")
+}
+
+func (a *App) setStatus(hints string) {
+	a.statusHints = hints
+	a.refreshStatus()
+}
+
+func (a *App) refreshStatus() {
+	text := a.statusHints
+	if tasks := a.taskStatus(); tasks != "" {
+		text = tasks + " | " + text
+	}
+	if a.modified {
+		text = "[Modified] | " + text
+	}
+	if a.vim != nil {
+		text = "-- " + a.vim.Mode.String() + " -- | " + text
+	}
+	a.statusBar.SetText(text)
+}
+
+func (a *App) showLog(visible bool) {
+	a.logVisible = visible
+	if visible {
+		a.layout.ResizeItem(a.logPanel, 8, 0)
+	} else {
+		a.layout.ResizeItem(a.logPanel, 0, 0)
+	}
+}
+
+func (a *App) toggleLog() {
+	a.showLog(!a.logVisible)
+}
+
+func (a *App) logf(format string, args ...interface{}) {
+	fmt.Fprintf(a.logPanel, "%s %s\n", time.Now().Format("15:04:05"), fmt.Sprintf(format, args...))
+	a.logPanel.ScrollToEnd()
+}
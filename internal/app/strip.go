@@ -0,0 +1,107 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"id3v2-tui/internal/metadata"
+	"id3v2-tui/internal/modals"
+	"id3v2-tui/internal/ui"
+)
+
+func (a *App) stripTags() {
+	a.confirmDiscard(a.chooseStrip)
+}
+
+func (a *App) chooseStrip() {
+	var scopes []string
+	var targets [][]string
+	if a.currentFile != "" {
+		scopes = append(scopes, "Current file")
+		targets = append(targets, []string{a.currentFile})
+	}
+	if len(a.selected) > 0 {
+		scopes = append(scopes, fmt.Sprintf("Selected files (%d)", len(a.selected)))
+		targets = append(targets, a.selectedPaths())
+	}
+	if len(scopes) == 0 {
+		a.showMessage("Open or select a file first")
+		return
+	}
+
+	profiles := metadata.Profiles
+	options := []string{"Strip all tags (ID3v2, ID3v1, APE)"}
+	for _, p := range profiles {
+		options = append(options, "Profile: "+p.Name)
+	}
+	choices := []modals.Choice{
+		{Label: "Clean up", Options: options},
+		{Label: "Apply to", Options: scopes},
+	}
+	modals.ShowChoices(a.app, a.root, "Strip tags", choices, func(selected []int) {
+		title := "Tags to strip"
+		plan := metadata.PlanStrip
+		apply := func(path string) error {
+			_, err := metadata.Strip(path)
+			return err
+		}
+		if selected[0] > 0 {
+			profile := profiles[selected[0]-1]
+			title = "Frames dropped by " + profile.Name
+			plan = func(path string) ([]string, error) {
+				return metadata.PlanProfile(path, profile)
+			}
+			apply = func(path string) error {
+				_, err := metadata.ApplyProfile(path, profile)
+				return err
+			}
+		}
+
+		var b strings.Builder
+		var paths, errs []string
+		for _, path := range targets[selected[1]] {
+			dropped, err := plan(path)
+			if err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			if len(dropped) > 0 {
+				paths = append(paths, path)
+				fmt.Fprintf(&b, "%s\n  %s\n\n", a.relPath(path), strings.Join(dropped, "\n  "))
+			}
+		}
+		if len(errs) > 0 {
+			a.showError(strings.Join(errs, "\n"))
+			return
+		}
+		if len(paths) == 0 {
+			a.showMessage("Nothing to remove")
+			return
+		}
+
+		modals.ShowPreview(a.app, a.root, title, b.String(), func() {
+			stripped := 0
+			var errs []string
+			for _, path := range paths {
+				if err := apply(path); err != nil {
+					errs = append(errs, a.relPath(path)+": "+err.Error())
+					continue
+				}
+				stripped++
+				if path == a.currentFile {
+					a.reloadCurrent()
+				}
+			}
+			a.logf("%s: cleaned up %d files", options[selected[0]], stripped)
+			a.showBatchResult(stripped, errs)
+		})
+	})
+}
+
+func (a *App) reloadCurrent() {
+	err := a.readMetadata(a.currentFile)
+	a.setReadError(err)
+	a.originalMeta = a.meta.Clone()
+	ui.PopulateForm(a.form, a.meta)
+	a.updateModified()
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+var spinner = []rune("⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏")
+
+type task struct {
+	label  string
+	done   int
+	total  int
+	frame  int
+	cancel context.CancelFunc
+}
+
+func (t *task) String() string {
+	frame := spinner[t.frame%len(spinner)]
+	if t.total == 0 {
+		return fmt.Sprintf("%c %s", frame, t.label)
+	}
+	return fmt.Sprintf("%c %s %d/%d", frame, t.label, t.done, t.total)
+}
+
+func (a *App) startTask(name, label string) (context.Context, *task) {
+	a.stopTask(name)
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &task{label: label, cancel: cancel}
+	if a.tasks == nil {
+		a.tasks = make(map[string]*task)
+	}
+	a.tasks[name] = t
+	a.refreshStatus()
+
+	go func() {
+		ticker := time.NewTicker(120 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.update(ctx, func() {
+					t.frame++
+					a.refreshStatus()
+				})
+			}
+		}
+	}()
+	return ctx, t
+}
+
+func (a *App) stopTask(name string) {
+	if t, ok := a.tasks[name]; ok {
+		t.cancel()
+		delete(a.tasks, name)
+		a.refreshStatus()
+	}
+}
+
+func (a *App) finishTask(name string, t *task) {
+	if a.tasks[name] == t {
+		a.stopTask(name)
+	}
+}
+
+func (a *App) update(ctx context.Context, fn func()) {
+	a.app.QueueUpdateDraw(func() {
+		if ctx.Err() == nil {
+			fn()
+		}
+	})
+}
+
+func (a *App) taskStatus() string {
+	names := make([]string, 0, len(a.tasks))
+	for name := range a.tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = a.tasks[name].String()
+	}
+	return strings.Join(parts, " | ")
+}
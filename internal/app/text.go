@@ -0,0 +1,175 @@
+package app
+
+import (
+	"strings"
+
+	"id3v2-tui/internal/hooks"
+	"id3v2-tui/internal/metadata"
+	"id3v2-tui/internal/modals"
+	"id3v2-tui/internal/textops"
+	"id3v2-tui/internal/ui"
+)
+
+func (a *App) textActions() {
+	actions := textops.Actions()
+	labels := make([]string, len(actions))
+	for i, action := range actions {
+		labels[i] = action.Label
+	}
+
+	scopes := []string{"Form"}
+	if a.fileList != nil {
+		scopes = append(scopes, "Selected files", "Current directory (recursive)")
+	}
+
+	modals.ShowTextActions(a.app, a.root, labels, scopes, func(index int, fieldList string, scope int) {
+		fields, err := metadata.ParseFields(fieldList)
+		if err != nil {
+			a.showError(err.Error())
+			return
+		}
+		if index < 0 || scope < 0 {
+			return
+		}
+		action := actions[index]
+
+		if scope == 0 {
+			a.applyTextActionToForm(action, fields)
+			return
+		}
+		a.applyTextActionToBatch(action, fields, scope == 2)
+	})
+}
+
+func (a *App) applyTextActionToForm(action textops.Action, fields []string) {
+	values := ui.FormValues(a.form)
+	var visible []string
+	for _, name := range fields {
+		if _, ok := values[name]; ok {
+			visible = append(visible, name)
+		}
+	}
+	updated := textops.ApplyMetadata(a.formMetadata(values), action, visible)
+	ui.PopulateForm(a.form, updated)
+
+	diff := a.originalMeta.Diff(updated)
+	if diff == "" {
+		a.showMessage("No changes detected")
+		return
+	}
+	a.showMessage(action.Label + " applied. Unsaved changes:\n\n" + diff)
+}
+
+func (a *App) applyTextActionToBatch(action textops.Action, fields []string, recursive bool) {
+	paths, err := a.batchPaths(recursive)
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	if len(paths) == 0 {
+		a.showMessage("No files selected")
+		return
+	}
+
+	metas, err := readAll(paths, make(map[string]*metadata.Metadata))
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	var items []modals.ChecklistItem
+	var changedPaths []string
+	var updated []*metadata.Metadata
+	for i, path := range paths {
+		after := textops.ApplyMetadata(metas[i], action, fields)
+		diff := metas[i].Diff(after)
+		if diff == "" {
+			continue
+		}
+		items = append(items, modals.ChecklistItem{
+			Label:   a.relPath(path),
+			Detail:  strings.ReplaceAll(diff, "\n", "; "),
+			Checked: true,
+		})
+		changedPaths = append(changedPaths, path)
+		updated = append(updated, after)
+	}
+	if len(items) == 0 {
+		a.showMessage("No changes detected")
+		return
+	}
+
+	modals.ShowChecklist(a.app, a.root, action.Label, items, func(items []modals.ChecklistItem) {
+		var paths []string
+		var metas []*metadata.Metadata
+		for i, item := range items {
+			if item.Checked {
+				paths = append(paths, changedPaths[i])
+				metas = append(metas, updated[i])
+			}
+		}
+		a.showBatchResult(a.saveAll(paths, metas))
+	})
+}
+
+func (a *App) fixEncoding() {
+	a.confirmDiscard(a.repairEncoding)
+}
+
+func (a *App) repairEncoding() {
+	path := a.currentFile
+	if path == "" {
+		a.showMessage("Open a file first")
+		return
+	}
+
+	found, err := metadata.FindMojibake(path)
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	if len(found) == 0 {
+		a.showMessage("No mis-decoded text found")
+		return
+	}
+
+	choices := make([]modals.Choice, len(found))
+	for i, f := range found {
+		options := []string{"Keep: " + f.Original}
+		for _, c := range f.Candidates {
+			options = append(options, c.Encoding+": "+c.Text)
+		}
+		choices[i] = modals.Choice{Label: f.Label, Options: options}
+	}
+
+	modals.ShowChoices(a.app, a.root, "Fix encoding", choices, func(selected []int) {
+		original, err := metadata.Read(path)
+		if err != nil {
+			a.showError(err.Error())
+			return
+		}
+		fixed := original.Clone()
+		for i, option := range selected {
+			if option > 0 {
+				fixed.Set(found[i].Field, found[i].Candidates[option-1].Text)
+			}
+		}
+
+		diff := original.Diff(fixed)
+		if diff == "" {
+			a.showMessage("No changes detected")
+			return
+		}
+
+		modals.ShowPreview(a.app, a.root, "Fix encoding", diff+"\n\nFixed text will be re-saved as Unicode.", func() {
+			if err := metadata.Save(path, fixed); err != nil {
+				a.showError(err.Error())
+				return
+			}
+			a.runHooks(hooks.EventSave, []hooks.FileChange{{Path: path, Changes: hooks.Changes(original, fixed)}})
+			a.meta = fixed
+			a.originalMeta = fixed.Clone()
+			ui.PopulateForm(a.form, fixed)
+			a.showMessage("Encoding fixed\n\n" + diff)
+		})
+	})
+}
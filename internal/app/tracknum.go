@@ -0,0 +1,55 @@
+package app
+
+import (
+	"id3v2-tui/internal/metadata"
+	"id3v2-tui/internal/modals"
+	"id3v2-tui/internal/tracknum"
+)
+
+func (a *App) numberTracks() {
+	choices := []modals.Choice{
+		{Label: "Order", Options: []string{"List order", "Natural filename order"}},
+		{Label: "Apply to", Options: []string{"Current directory", "Selected files"}},
+	}
+
+	modals.ShowChoices(a.app, a.root, "Number tracks", choices, func(selected []int) {
+		var paths []string
+		var err error
+		if selected[1] == 1 {
+			paths = a.selectedPaths()
+		} else {
+			paths, err = tracknum.Collect(a.currentDir)
+		}
+		if err != nil {
+			a.showError(err.Error())
+			return
+		}
+		if selected[0] == 0 {
+			paths = a.displayOrder(paths)
+		}
+		if len(paths) == 0 {
+			a.showMessage("No files to number")
+			return
+		}
+
+		assignments := tracknum.Plan(paths, selected[0] == 1)
+		modals.ShowPreview(a.app, a.root, "Number tracks", tracknum.Preview(a.currentDir, assignments), func() {
+			paths := make([]string, len(assignments))
+			for i, assignment := range assignments {
+				paths[i] = assignment.Path
+			}
+			metas, err := readAll(paths, make(map[string]*metadata.Metadata))
+			if err != nil {
+				a.showError(err.Error())
+				return
+			}
+			for i, assignment := range assignments {
+				metas[i].Track = assignment.Track
+				if assignment.Disc != "" {
+					metas[i].Disc = assignment.Disc
+				}
+			}
+			a.showBatchResult(a.saveAll(paths, metas))
+		})
+	})
+}
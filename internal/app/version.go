@@ -0,0 +1,45 @@
+package app
+
+import (
+	"fmt"
+
+	"id3v2-tui/internal/metadata"
+	"id3v2-tui/internal/modals"
+)
+
+func (a *App) convertVersion() {
+	var scopes []string
+	var targets [][]string
+	if a.currentFile != "" {
+		scopes = append(scopes, "Current file")
+		targets = append(targets, []string{a.currentFile})
+	}
+	if len(a.selected) > 0 {
+		scopes = append(scopes, fmt.Sprintf("Selected files (%d)", len(a.selected)))
+		targets = append(targets, a.selectedPaths())
+	}
+	if len(scopes) == 0 {
+		a.showMessage("Open or select a file first")
+		return
+	}
+
+	versions := []byte{4, 3}
+	choices := []modals.Choice{
+		{Label: "Convert to", Options: []string{"ID3v2.4 (UTF-8)", "ID3v2.3 (UTF-16)"}},
+		{Label: "Apply to", Options: scopes},
+	}
+	modals.ShowChoices(a.app, a.root, "Convert tag version", choices, func(selected []int) {
+		version := versions[selected[0]]
+		converted := 0
+		var errs []string
+		for _, path := range targets[selected[1]] {
+			if err := metadata.ConvertVersion(path, version); err != nil {
+				errs = append(errs, a.relPath(path)+": "+err.Error())
+				continue
+			}
+			converted++
+		}
+		a.logf("converted %d files to ID3v2.%d", converted, version)
+		a.showBatchResult(converted, errs)
+	})
+}
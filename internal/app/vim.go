@@ -0,0 +1,29 @@
+package app
+
+import "github.com/gdamore/tcell/v2"
+
+func (a *App) openCommandLine(prompt string, onSubmit func(text string)) {
+	a.openPrompt(prompt, "", nil, func(text string, submitted bool) {
+		if submitted {
+			onSubmit(text)
+		}
+	})
+}
+
+func (a *App) openPrompt(prompt, text string, onChange func(text string), onDone func(text string, submitted bool)) {
+	previous := a.app.GetFocus()
+	a.cmdline.SetChangedFunc(nil)
+	a.cmdline.SetLabel(prompt).SetText(text)
+	a.cmdline.SetChangedFunc(onChange)
+	a.layout.ResizeItem(a.statusBar, 0, 0)
+	a.layout.ResizeItem(a.cmdline, 1, 0)
+	a.cmdline.SetDoneFunc(func(key tcell.Key) {
+		text := a.cmdline.GetText()
+		a.cmdline.SetChangedFunc(nil)
+		a.layout.ResizeItem(a.cmdline, 0, 0)
+		a.layout.ResizeItem(a.statusBar, 1, 0)
+		a.app.SetFocus(previous)
+		onDone(text, key == tcell.KeyEnter)
+	})
+	a.app.SetFocus(a.cmdline)
+}
@@ -0,0 +1,527 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"id3v2-tui/internal/exchange"
+	"id3v2-tui/internal/files"
+	"id3v2-tui/internal/library"
+	"id3v2-tui/internal/metadata"
+)
+
+const (
+	ExitOK        = 0
+	ExitChanged   = ExitOK
+	ExitError     = 1
+	ExitUnchanged = 2
+)
+
+type command struct {
+	name      string
+	usage     string
+	flags     []string
+	boolFlags []string
+	run       func(c *invocation) int
+}
+
+type invocation struct {
+	files  []string
+	flags  map[string][]string
+	stdout io.Writer
+	stderr io.Writer
+}
+
+var commands []command
+
+func init() {
+	setFlags := append(metadata.Fields(), "cover")
+	commands = []command{
+		{"get", "get FILE... [--field NAME]...", []string{"field"}, nil, runGet},
+		{"set", "set FILE... [--" + strings.Join(setFlags, " VALUE] [--") + " VALUE]", setFlags, nil, runSet},
+		{"clear", "clear FILE... --field NAME [--field NAME]...", []string{"field"}, nil, runClear},
+		{"export", "export FILE|DIR... [--output FILE] [--format json|csv|tsv]", []string{"output", "format"}, nil, runExport},
+		{"import", "import FILE.json|FILE.csv|FILE.tsv [--dry-run]", nil, []string{"dry-run"}, runImport},
+		{"strip", "strip FILE... [--profile NAME] [--dry-run]", []string{"profile"}, []string{"dry-run"}, runStrip},
+		{"stats", "stats FILE|DIR...", nil, nil, runStats},
+	}
+}
+
+func IsCommand(name string) bool {
+	if name == "help" || name == "--help" || name == "-h" {
+		return true
+	}
+	_, ok := lookup(name)
+	return ok
+}
+
+func lookup(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+func Usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  id3v2-tui [FILE]")
+	for _, c := range commands {
+		fmt.Fprintln(w, "  id3v2-tui "+c.usage)
+	}
+	fmt.Fprintf(w, "\nFields: %s\n", strings.Join(metadata.Fields(), ", "))
+	fmt.Fprintf(w, "Profiles: %s\n", strings.Join(metadata.ProfileNames(), ", "))
+	fmt.Fprintf(w, "Exit codes: %d changed, %d error, %d unchanged\n", ExitChanged, ExitError, ExitUnchanged)
+}
+
+func Run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || !IsCommand(args[0]) {
+		Usage(stderr)
+		return ExitError
+	}
+
+	c, ok := lookup(args[0])
+	if !ok {
+		Usage(stdout)
+		return ExitOK
+	}
+
+	files, flags, err := parseArgs(args[1:], c.flags, c.boolFlags)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", c.name, err)
+		fmt.Fprintln(stderr, "usage: id3v2-tui "+c.usage)
+		return ExitError
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(stderr, "%s: no files given\n", c.name)
+		fmt.Fprintln(stderr, "usage: id3v2-tui "+c.usage)
+		return ExitError
+	}
+
+	return c.run(&invocation{files: files, flags: flags, stdout: stdout, stderr: stderr})
+}
+
+func parseArgs(args []string, allowed, boolean []string) ([]string, map[string][]string, error) {
+	isAllowed := make(map[string]bool, len(allowed))
+	for _, name := range allowed {
+		isAllowed[name] = true
+	}
+	isBool := make(map[string]bool, len(boolean))
+	for _, name := range boolean {
+		isBool[name] = true
+	}
+
+	var files []string
+	flags := make(map[string][]string)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			files = append(files, args[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "--") {
+			files = append(files, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		name = strings.ToLower(name)
+		if isBool[name] {
+			if hasValue {
+				return nil, nil, fmt.Errorf("flag --%s does not take a value", name)
+			}
+			flags[name] = append(flags[name], "true")
+			continue
+		}
+		if !isAllowed[name] {
+			return nil, nil, fmt.Errorf("unknown flag --%s", name)
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("flag --%s requires a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		flags[name] = append(flags[name], value)
+	}
+	return files, flags, nil
+}
+
+func (c *invocation) fieldFlags() ([]string, error) {
+	var names []string
+	for _, value := range c.flags["field"] {
+		parsed, err := metadata.ParseFields(value)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, parsed...)
+	}
+	return names, nil
+}
+
+func (c *invocation) fail(path string, err error) {
+	fmt.Fprintf(c.stderr, "%s: %s\n", path, err)
+}
+
+func read(path string) (*metadata.Metadata, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	return metadata.Read(path)
+}
+
+func exitCode(failed, changed int) int {
+	switch {
+	case failed > 0:
+		return ExitError
+	case changed > 0:
+		return ExitChanged
+	default:
+		return ExitUnchanged
+	}
+}
+
+func runGet(c *invocation) int {
+	names, err := c.fieldFlags()
+	if err != nil {
+		fmt.Fprintf(c.stderr, "get: %s\n", err)
+		return ExitError
+	}
+	if len(names) == 0 {
+		names = metadata.Fields()
+	}
+
+	failed := 0
+	for _, path := range c.files {
+		meta, err := read(path)
+		if err != nil {
+			c.fail(path, err)
+			failed++
+			continue
+		}
+
+		prefix := ""
+		if len(c.files) > 1 {
+			prefix = path + "\t"
+		}
+		if len(names) == 1 {
+			value, _ := meta.Get(names[0])
+			fmt.Fprintln(c.stdout, prefix+value)
+			continue
+		}
+		for _, name := range names {
+			value, _ := meta.Get(name)
+			fmt.Fprintf(c.stdout, "%s%s: %s\n", prefix, name, value)
+		}
+	}
+
+	if failed > 0 {
+		return ExitError
+	}
+	return ExitOK
+}
+
+func runSet(c *invocation) int {
+	if len(c.flags) == 0 {
+		fmt.Fprintln(c.stderr, "set: no fields given")
+		return ExitError
+	}
+
+	failed, changed := 0, 0
+	for _, path := range c.files {
+		before, err := read(path)
+		if err != nil {
+			c.fail(path, err)
+			failed++
+			continue
+		}
+
+		after := before.Clone()
+		for name, values := range c.flags {
+			value := values[len(values)-1]
+			if name == "cover" {
+				after.CoverPath = value
+				continue
+			}
+			after.Set(name, value)
+		}
+
+		if before.Diff(after) == "" && after.CoverPath == "" {
+			continue
+		}
+		if _, errs := exchange.Apply([]*exchange.Change{{Path: path, Before: before, After: after}}); len(errs) > 0 {
+			c.fail(path, errs[0].Err)
+			failed++
+			continue
+		}
+		changed++
+	}
+
+	return exitCode(failed, changed)
+}
+
+func runClear(c *invocation) int {
+	names, err := c.fieldFlags()
+	if err != nil {
+		fmt.Fprintf(c.stderr, "clear: %s\n", err)
+		return ExitError
+	}
+	if len(names) == 0 {
+		fmt.Fprintln(c.stderr, "clear: no fields given")
+		return ExitError
+	}
+
+	failed, changed := 0, 0
+	for _, path := range c.files {
+		before, err := read(path)
+		if err != nil {
+			c.fail(path, err)
+			failed++
+			continue
+		}
+
+		empty := true
+		for _, name := range names {
+			if value, _ := before.Get(name); value != "" {
+				empty = false
+			}
+		}
+		if empty {
+			continue
+		}
+
+		if err := metadata.Clear(path, names); err != nil {
+			c.fail(path, err)
+			failed++
+			continue
+		}
+		changed++
+	}
+
+	return exitCode(failed, changed)
+}
+
+func (c *invocation) flag(name string) string {
+	values := c.flags[name]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
+
+func (c *invocation) report(errs []exchange.FileError) {
+	for _, err := range errs {
+		fmt.Fprintln(c.stderr, err.Error())
+	}
+}
+
+func exportFormat(format, output string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(output)) {
+		case ".csv":
+			return "csv", nil
+		case ".tsv":
+			return "tsv", nil
+		}
+		return "json", nil
+	}
+	format = strings.ToLower(format)
+	switch format {
+	case "json", "csv", "tsv":
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown format %q", format)
+}
+
+func runExport(c *invocation) int {
+	format, err := exportFormat(c.flag("format"), c.flag("output"))
+	if err != nil {
+		fmt.Fprintf(c.stderr, "export: %s\n", err)
+		return ExitError
+	}
+
+	paths, errs := exchange.Expand(c.files)
+
+	out := c.stdout
+	if output := c.flag("output"); output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "export: %s\n", err)
+			return ExitError
+		}
+		defer f.Close()
+		out = f
+	}
+
+	switch format {
+	case "csv", "tsv":
+		comma := ','
+		if format == "tsv" {
+			comma = '\t'
+		}
+		errs = append(errs, exchange.WriteCSV(out, paths, comma)...)
+	default:
+		doc, exportErrs := exchange.Export(paths)
+		errs = append(errs, exportErrs...)
+		if err := exchange.WriteJSON(out, doc); err != nil {
+			errs = append(errs, exchange.FileError{Path: "(output)", Err: err})
+		}
+	}
+
+	c.report(errs)
+	if len(errs) > 0 {
+		return ExitError
+	}
+	return ExitOK
+}
+
+func plan(path string) ([]*exchange.Change, []exchange.FileError, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv", ".tsv":
+		return exchange.PlanCSV(f, exchange.Delimiter(path))
+	}
+
+	doc, err := exchange.ReadJSON(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	changes, errs := exchange.PlanJSON(doc)
+	return changes, errs, nil
+}
+
+func runImport(c *invocation) int {
+	if len(c.files) != 1 {
+		fmt.Fprintln(c.stderr, "import: expected exactly one JSON, CSV or TSV file")
+		return ExitError
+	}
+
+	changes, errs, err := plan(c.files[0])
+	if err != nil {
+		fmt.Fprintf(c.stderr, "import: %s\n", err)
+		return ExitError
+	}
+	for _, change := range changes {
+		fmt.Fprintf(c.stdout, "%s\n  %s\n", change.Path, strings.ReplaceAll(change.Diff(), "\n", "\n  "))
+	}
+
+	saved := 0
+	if c.flag("dry-run") == "" {
+		var applyErrs []exchange.FileError
+		saved, applyErrs = exchange.Apply(changes)
+		errs = append(errs, applyErrs...)
+	} else {
+		saved = len(changes)
+	}
+	c.report(errs)
+
+	return exitCode(len(errs), saved)
+}
+
+func runStrip(c *invocation) int {
+	var profile metadata.Profile
+	if name := c.flag("profile"); name != "" {
+		var ok bool
+		profile, ok = metadata.LookupProfile(name)
+		if !ok {
+			fmt.Fprintf(c.stderr, "strip: unknown profile %q (profiles: %s)\n", name, strings.Join(metadata.ProfileNames(), ", "))
+			return ExitError
+		}
+	}
+	dryRun := c.flag("dry-run") != ""
+
+	failed, changed := 0, 0
+	for _, path := range c.files {
+		var dropped []string
+		var err error
+		if profile.Name != "" {
+			dropped, err = metadata.PlanProfile(path, profile)
+		} else {
+			dropped, err = metadata.PlanStrip(path)
+		}
+		if err != nil {
+			c.fail(path, err)
+			failed++
+			continue
+		}
+		if len(dropped) == 0 {
+			continue
+		}
+		fmt.Fprintf(c.stdout, "%s\n  %s\n", path, strings.Join(dropped, "\n  "))
+
+		if !dryRun {
+			if profile.Name != "" {
+				_, err = metadata.ApplyProfile(path, profile)
+			} else {
+				_, err = metadata.Strip(path)
+			}
+			if err != nil {
+				c.fail(path, err)
+				failed++
+				continue
+			}
+		}
+		changed++
+	}
+
+	return exitCode(failed, changed)
+}
+
+func runStats(c *invocation) int {
+	ix, err := library.OpenIndex(library.IndexPath)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "stats: %s\n", err)
+	}
+
+	paths, errs := exchange.Expand(c.files)
+	for i, path := range paths {
+		if abs, err := filepath.Abs(path); err == nil {
+			paths[i] = abs
+		}
+	}
+	entries := make([]library.Entry, 0, len(paths))
+	for _, path := range paths {
+		entries = append(entries, ix.Load(path))
+	}
+	for _, arg := range c.files {
+		if info, err := os.Stat(arg); err == nil && info.IsDir() {
+			if dir, err := filepath.Abs(arg); err == nil {
+				ix.Prune(dir, true, paths)
+			}
+		}
+	}
+	if err := ix.Save(); err != nil {
+		fmt.Fprintf(c.stderr, "stats: failed to save the tag index: %s\n", err)
+	}
+
+	s := library.Summarize(entries)
+	_, hits, misses := ix.Stats()
+	fmt.Fprintf(c.stdout, "Files:         %d\n", s.Files)
+	fmt.Fprintf(c.stdout, "Artists:       %d\n", s.Artists)
+	fmt.Fprintf(c.stdout, "Albums:        %d\n", s.Albums)
+	duration := library.FormatDuration(s.Duration)
+	if duration == "" {
+		duration = "0:00"
+	}
+	fmt.Fprintf(c.stdout, "Duration:      %s\n", duration)
+	fmt.Fprintf(c.stdout, "Size:          %s\n", files.FormatSize(s.Size))
+	fmt.Fprintf(c.stdout, "Without cover: %d\n", s.NoCover)
+	fmt.Fprintf(c.stdout, "Unreadable:    %d\n", s.Unreadable)
+	fmt.Fprintf(c.stdout, "Index:         %d cached, %d read\n", hits, misses)
+
+	c.report(errs)
+	if len(errs) > 0 {
+		return ExitError
+	}
+	return ExitOK
+}
@@ -0,0 +1,310 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"id3v2-tui/internal/library"
+	"id3v2-tui/internal/metadata"
+)
+
+const testFile = "../../test/test.mp3"
+
+func copyTestFile(t *testing.T) string {
+	data, err := os.ReadFile(testFile)
+	if os.IsNotExist(err) {
+		t.Skip("test.mp3 not found")
+	}
+	if err != nil {
+		t.Fatalf("failed to read test file: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "test.mp3")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+	return path
+}
+
+func run(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := Run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestIsCommand(t *testing.T) {
+	for _, name := range []string{"get", "set", "clear", "export", "import", "strip", "stats", "help", "--help"} {
+		if !IsCommand(name) {
+			t.Errorf("expected %q to be a command", name)
+		}
+	}
+	for _, name := range []string{"song.mp3", "", "/path/to/get"} {
+		if IsCommand(name) {
+			t.Errorf("expected %q not to be a command", name)
+		}
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	files, flags, err := parseArgs([]string{"a.mp3", "--artist", "X", "b.mp3", "--album=Y", "--dry-run", "--", "--c.mp3"}, []string{"artist", "album"}, []string{"dry-run"})
+	if err != nil {
+		t.Fatalf("parseArgs failed: %v", err)
+	}
+	if strings.Join(files, ",") != "a.mp3,b.mp3,--c.mp3" {
+		t.Errorf("unexpected files %v", files)
+	}
+	if flags["artist"][0] != "X" || flags["album"][0] != "Y" || flags["dry-run"][0] != "true" {
+		t.Errorf("unexpected flags %v", flags)
+	}
+
+	if _, _, err := parseArgs([]string{"--bogus", "x"}, []string{"artist"}, nil); err == nil {
+		t.Error("expected error for unknown flag")
+	}
+	if _, _, err := parseArgs([]string{"--artist"}, []string{"artist"}, nil); err == nil {
+		t.Error("expected error for missing value")
+	}
+}
+
+func TestSetGetClear(t *testing.T) {
+	path := copyTestFile(t)
+
+	code, _, stderr := run("set", path, "--artist", "CLI Artist", "--album", "CLI Album", "--comment", "note")
+	if code != ExitChanged {
+		t.Fatalf("expected exit %d, got %d: %s", ExitChanged, code, stderr)
+	}
+
+	code, _, _ = run("set", path, "--artist", "CLI Artist")
+	if code != ExitUnchanged {
+		t.Errorf("expected exit %d for unchanged set, got %d", ExitUnchanged, code)
+	}
+
+	code, stdout, _ := run("get", path, "--field", "artist")
+	if code != ExitOK || stdout != "CLI Artist\n" {
+		t.Errorf("unexpected get output %q (exit %d)", stdout, code)
+	}
+
+	code, stdout, _ = run("get", path)
+	if code != ExitOK || !strings.Contains(stdout, "album: CLI Album") || !strings.Contains(stdout, "comment: note") {
+		t.Errorf("unexpected get output %q", stdout)
+	}
+
+	code, _, _ = run("clear", path, "--field", "comment")
+	if code != ExitChanged {
+		t.Errorf("expected exit %d for clear, got %d", ExitChanged, code)
+	}
+	code, _, _ = run("clear", path, "--field", "comment")
+	if code != ExitUnchanged {
+		t.Errorf("expected exit %d for repeated clear, got %d", ExitUnchanged, code)
+	}
+
+	meta, err := metadata.Read(path)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if meta.Comment != "" || meta.Artist != "CLI Artist" {
+		t.Errorf("unexpected metadata %+v", meta)
+	}
+}
+
+func TestSetEmptyValueClears(t *testing.T) {
+	path := copyTestFile(t)
+
+	run("set", path, "--artist", "Someone", "--title", "Song")
+	code, _, stderr := run("set", path, "--artist", "")
+	if code != ExitChanged {
+		t.Fatalf("expected exit %d, got %d: %s", ExitChanged, code, stderr)
+	}
+
+	meta, err := metadata.Read(path)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if meta.Artist != "" || meta.TrackName != "Song" {
+		t.Errorf("unexpected metadata %+v", meta)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := [][]string{
+		{"get"},
+		{"get", "/nonexistent/file.mp3"},
+		{"get", "x.mp3", "--field", "bogus"},
+		{"set", "x.mp3"},
+		{"set", "x.mp3", "--bogus", "y"},
+		{"clear", "x.mp3"},
+	}
+
+	for _, args := range tests {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			code, _, stderr := run(args...)
+			if code != ExitError {
+				t.Errorf("expected exit %d, got %d", ExitError, code)
+			}
+			if stderr == "" {
+				t.Error("expected error output")
+			}
+		})
+	}
+}
+
+func TestHelp(t *testing.T) {
+	code, stdout, _ := run("help")
+	if code != ExitOK || !strings.Contains(stdout, "Usage:") {
+		t.Errorf("unexpected help output %q (exit %d)", stdout, code)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	path := copyTestFile(t)
+	run("set", path, "--artist", "Before", "--title", "Song")
+
+	out := filepath.Join(t.TempDir(), "tags.json")
+	code, _, stderr := run("export", path, "--output", out)
+	if code != ExitOK {
+		t.Fatalf("export failed with %d: %s", code, stderr)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("failed to read export: %v", err)
+	}
+	edited := strings.Replace(string(data), `"artist": "Before"`, `"artist": "After"`, 1)
+	if edited == string(data) {
+		t.Fatalf("expected artist in export, got %s", data)
+	}
+	if err := os.WriteFile(out, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, _ := run("import", out, "--dry-run")
+	if code != ExitChanged || !strings.Contains(stdout, "Artist: Before → After") {
+		t.Errorf("unexpected dry-run output %q (exit %d)", stdout, code)
+	}
+	if meta, _ := metadata.Read(path); meta.Artist != "Before" {
+		t.Error("expected dry run not to modify the file")
+	}
+
+	code, _, stderr = run("import", out)
+	if code != ExitChanged {
+		t.Fatalf("import failed with %d: %s", code, stderr)
+	}
+	if meta, _ := metadata.Read(path); meta.Artist != "After" || meta.TrackName != "Song" {
+		t.Errorf("unexpected metadata after import %+v", meta)
+	}
+
+	code, _, _ = run("import", out)
+	if code != ExitUnchanged {
+		t.Errorf("expected exit %d for repeated import, got %d", ExitUnchanged, code)
+	}
+}
+
+func TestExportImportCSV(t *testing.T) {
+	path := copyTestFile(t)
+	run("set", path, "--album", "Old Album")
+
+	out := filepath.Join(t.TempDir(), "tags.tsv")
+	code, _, stderr := run("export", path, "--output", out)
+	if code != ExitOK {
+		t.Fatalf("export failed with %d: %s", code, stderr)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("failed to read export: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "path\ttitle\t") {
+		t.Fatalf("expected TSV header, got %q", data)
+	}
+	edited := strings.Replace(string(data), "Old Album", "New Album", 1)
+	if err := os.WriteFile(out, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, stderr := run("import", out)
+	if code != ExitChanged || !strings.Contains(stdout, "Album: Old Album → New Album") {
+		t.Errorf("unexpected import output %q %q (exit %d)", stdout, stderr, code)
+	}
+}
+
+func TestExportFormat(t *testing.T) {
+	tests := []struct {
+		format, output, expected string
+	}{
+		{"", "", "json"},
+		{"", "tags.CSV", "csv"},
+		{"", "tags.tsv", "tsv"},
+		{"json", "tags.csv", "json"},
+	}
+	for _, tt := range tests {
+		if got, err := exportFormat(tt.format, tt.output); err != nil || got != tt.expected {
+			t.Errorf("exportFormat(%q, %q) = %q, %v, expected %q", tt.format, tt.output, got, err, tt.expected)
+		}
+	}
+	if _, err := exportFormat("xml", ""); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestStrip(t *testing.T) {
+	path := copyTestFile(t)
+	if code, _, stderr := run("set", path, "--title", "Title", "--comment", "Ripped"); code != ExitChanged {
+		t.Fatalf("set failed: %s", stderr)
+	}
+
+	code, stdout, _ := run("strip", path, "--profile", "minimal", "--dry-run")
+	if code != ExitChanged || !strings.Contains(stdout, "COMM: Ripped") || strings.Contains(stdout, "TIT2") {
+		t.Errorf("unexpected dry run (%d):\n%s", code, stdout)
+	}
+	if meta, _ := metadata.Read(path); meta.Comment != "Ripped" {
+		t.Error("a dry run should not change the file")
+	}
+
+	if code, _, stderr := run("strip", path, "--profile", "minimal"); code != ExitChanged {
+		t.Fatalf("strip --profile failed (%d): %s", code, stderr)
+	}
+	if meta, _ := metadata.Read(path); meta.TrackName != "Title" || meta.Comment != "" {
+		t.Errorf("unexpected metadata after the profile %+v", meta)
+	}
+
+	if code, stdout, _ := run("strip", path); code != ExitChanged || !strings.Contains(stdout, "ID3v2.4 tag") {
+		t.Errorf("unexpected strip output (%d):\n%s", code, stdout)
+	}
+	if meta, _ := metadata.Read(path); meta.TrackName != "" {
+		t.Errorf("expected no tags after strip, got %+v", meta)
+	}
+	if code, _, _ := run("strip", path); code != ExitUnchanged {
+		t.Errorf("stripping an untagged file should report unchanged, got %d", code)
+	}
+
+	if code, _, stderr := run("strip", path, "--profile", "nope"); code != ExitError || !strings.Contains(stderr, "unknown profile") {
+		t.Errorf("expected an unknown profile error, got %d: %s", code, stderr)
+	}
+}
+
+func TestStats(t *testing.T) {
+	path := copyTestFile(t)
+	run("set", path, "--artist", "Artist", "--album", "Album")
+	if err := os.WriteFile(filepath.Join(filepath.Dir(path), "broken.mp3"), []byte("not audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	library.IndexPath = filepath.Join(t.TempDir(), "index.gob")
+	defer func() { library.IndexPath = "" }()
+
+	code, stdout, stderr := run("stats", filepath.Dir(path))
+	if code != ExitOK {
+		t.Fatalf("stats failed (%d): %s", code, stderr)
+	}
+	for _, want := range []string{"Files:         2", "Artists:       1", "Albums:        1", "Index:         0 cached, 2 read"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected %q in stats output:\n%s", want, stdout)
+		}
+	}
+
+	if _, stdout, _ = run("stats", filepath.Dir(path)); !strings.Contains(stdout, "Index:         2 cached, 0 read") {
+		t.Errorf("expected the second run to use the index:\n%s", stdout)
+	}
+}
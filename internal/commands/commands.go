@@ -2,11 +2,15 @@ package commands
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
+	"strings"
 )
 
 type Executor interface {
 	Run(name string, args ...string) (string, error)
+	RunInteractive(name string, args ...string) error
+	RunWithInput(input string, env []string, name string, args ...string) (string, error)
 }
 
 type CommandExecutor struct{}
@@ -20,6 +24,37 @@ func (e CommandExecutor) Run(name string, args ...string) (string, error) {
 	return string(output), nil
 }
 
+func (e CommandExecutor) RunInteractive(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+func (e CommandExecutor) RunWithInput(input string, env []string, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Env = append(os.Environ(), env...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(output), fmt.Errorf("%s: %w", name, err)
+	}
+	return string(output), nil
+}
+
 func NewExecutor() Executor {
 	return CommandExecutor{}
 }
+
+func EditorCommand() (string, []string) {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return fields[0], fields[1:]
+		}
+	}
+	return "vi", nil
+}
@@ -26,3 +26,49 @@ func TestRunCommandError(t *testing.T) {
 		t.Fatal("expected error for nonexistent command")
 	}
 }
+
+func TestRunInteractive(t *testing.T) {
+	executor := NewExecutor()
+
+	if err := executor.RunInteractive("true"); err != nil {
+		t.Fatalf("RunInteractive failed: %v", err)
+	}
+	if err := executor.RunInteractive("false"); err == nil {
+		t.Fatal("expected error for failing command")
+	}
+}
+
+func TestRunWithInput(t *testing.T) {
+	executor := NewExecutor()
+
+	output, err := executor.RunWithInput("from stdin", []string{"GREETING=hello"}, "sh", "-c", "echo $GREETING; cat")
+	if err != nil {
+		t.Fatalf("RunWithInput failed: %v", err)
+	}
+	if output != "hello\nfrom stdin" {
+		t.Errorf("unexpected output %q", output)
+	}
+
+	output, err = executor.RunWithInput("", nil, "sh", "-c", "echo failed; exit 3")
+	if err == nil {
+		t.Fatal("expected error for failing command")
+	}
+	if output != "failed\n" {
+		t.Errorf("expected output to be kept on failure, got %q", output)
+	}
+}
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "code --wait")
+
+	name, args := EditorCommand()
+	if name != "code" || len(args) != 1 || args[0] != "--wait" {
+		t.Errorf("unexpected editor command %q %v", name, args)
+	}
+
+	t.Setenv("EDITOR", "")
+	if name, _ := EditorCommand(); name != "vi" {
+		t.Errorf("expected vi fallback, got %q", name)
+	}
+}
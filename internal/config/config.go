@@ -0,0 +1,583 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"id3v2-tui/internal/files"
+	"id3v2-tui/internal/hooks"
+	"id3v2-tui/internal/keymap"
+	"id3v2-tui/internal/library"
+	"id3v2-tui/internal/metadata"
+	"id3v2-tui/internal/textops"
+	"id3v2-tui/internal/theme"
+)
+
+var DefaultFormFields = []string{"title", "artist", "album", "cover"}
+
+type Backup struct {
+	Policy string
+	Dir    string
+}
+
+type Config struct {
+	StartDir    string
+	FormFields  []string
+	TagVersion  int
+	Encoding    string
+	Extensions  []string
+	Backup      Backup
+	Hooks       hooks.Hooks
+	Theme       string
+	Themes      []theme.Theme
+	Keymap      *keymap.Keymap
+	Vim         bool
+	ConfirmSave bool
+	Profiles    []metadata.Profile
+	Browser     files.View
+	Index       bool
+	SmallWords  []string
+	themeLine   int
+	bindings    []binding
+	profiles    map[string]bool
+}
+
+type binding struct {
+	ctx    keymap.Context
+	action string
+	keys   []string
+	line   int
+}
+
+func Default() *Config {
+	return &Config{
+		FormFields:  append([]string(nil), DefaultFormFields...),
+		TagVersion:  4,
+		Encoding:    "utf-8",
+		Extensions:  []string{".mp3"},
+		Backup:      Backup{Policy: metadata.BackupNone},
+		Hooks:       make(hooks.Hooks),
+		Theme:       theme.Dark.Name,
+		Themes:      theme.Builtin(),
+		Keymap:      keymap.Default(),
+		ConfirmSave: true,
+		Profiles:    metadata.DefaultProfiles(),
+		Browser:     files.DefaultView(),
+		Index:       true,
+		SmallWords:  append([]string(nil), textops.DefaultSmallWords...),
+	}
+}
+
+func Path() string {
+	return xdgPath("XDG_CONFIG_HOME", ".config", "config.toml")
+}
+
+func CachePath(name string) string {
+	return xdgPath("XDG_CACHE_HOME", ".cache", name)
+}
+
+func StatePath(name string) string {
+	return xdgPath("XDG_STATE_HOME", filepath.Join(".local", "state"), name)
+}
+
+func xdgPath(env, fallback, name string) string {
+	dir := os.Getenv(env)
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, fallback)
+	}
+	return filepath.Join(dir, "id3v2-tui", name)
+}
+
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	if path == "" {
+		return cfg, nil
+	}
+
+	var errs []error
+	f, err := os.Open(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		entries, err := parse(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for _, e := range entries {
+			if err := cfg.set(e); err != nil {
+				errs = append(errs, fmt.Errorf("%s:%d: %w", path, e.value.line, err))
+			}
+		}
+		for _, b := range cfg.bindings {
+			cfg.Keymap.Unbind(b.ctx, b.action)
+		}
+		for _, b := range cfg.bindings {
+			if err := cfg.Keymap.Bind(b.ctx, b.action, b.keys); err != nil {
+				errs = append(errs, fmt.Errorf("%s:%d: %s: %w", path, b.line, keySection(b.ctx), err))
+			}
+		}
+	}
+
+	themes, err := LoadThemes(filepath.Join(filepath.Dir(path), "themes"))
+	if err != nil {
+		errs = append(errs, err)
+	}
+	cfg.Themes = mergeThemes(cfg.Themes, themes)
+	if !cfg.hasTheme(cfg.Theme) {
+		names := make([]string, len(cfg.Themes))
+		for i, t := range cfg.Themes {
+			names[i] = t.Name
+		}
+		errs = append(errs, fmt.Errorf("%s:%d: ui.theme: unknown theme %q (available: %s)", path, cfg.themeLine, cfg.Theme, strings.Join(names, ", ")))
+	}
+	return cfg, errors.Join(errs...)
+}
+
+func mergeThemes(base, extra []theme.Theme) []theme.Theme {
+	merged := append([]theme.Theme(nil), base...)
+	for _, t := range extra {
+		replaced := false
+		for i := range merged {
+			if merged[i].Name == t.Name {
+				merged[i] = t
+				replaced = true
+			}
+		}
+		if !replaced {
+			merged = append(merged, t)
+		}
+	}
+	return merged
+}
+
+func (c *Config) hasTheme(name string) bool {
+	for _, t := range c.Themes {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+type setter func(c *Config, v value) error
+
+var keys = map[string]setter{
+	"start_dir":          setStartDir,
+	"form.fields":        setFormFields,
+	"tags.version":       setTagVersion,
+	"tags.encoding":      setEncoding,
+	"files.extensions":   setExtensions,
+	"backup.policy":      setBackupPolicy,
+	"backup.dir":         setBackupDir,
+	"ui.theme":           setTheme,
+	"ui.vim":             setVim,
+	"ui.confirm_save":    setConfirmSave,
+	"browser.sort":       setBrowserSort,
+	"browser.descending": setBrowserDescending,
+	"browser.hidden":     setBrowserHidden,
+	"browser.columns":    setBrowserColumns,
+	"cache.index":        setIndex,
+	"text.small_words":   setSmallWords,
+}
+
+func init() {
+	for _, event := range hooks.Events() {
+		keys["hooks."+string(event)] = hookSetter(event)
+	}
+}
+
+func keySection(ctx keymap.Context) string {
+	if ctx == keymap.ContextGlobal {
+		return "keys"
+	}
+	return "keys." + string(ctx)
+}
+
+func (c *Config) setKey(e entry) error {
+	var ctx keymap.Context
+	var sections []string
+	for _, candidate := range keymap.Contexts() {
+		sections = append(sections, "["+keySection(candidate)+"]")
+		if keySection(candidate) == e.section {
+			ctx = candidate
+		}
+	}
+	if ctx == "" {
+		return fmt.Errorf("unknown section [%s] (key sections are %s)", e.section, strings.Join(sections, ", "))
+	}
+
+	if _, ok := keymap.LookupAction(e.key); !ok {
+		var names []string
+		for _, a := range keymap.Actions() {
+			names = append(names, a.Name)
+		}
+		return fmt.Errorf("unknown action %q (valid actions: %s)", e.key, strings.Join(names, ", "))
+	}
+
+	var keys []string
+	switch e.value.kind {
+	case "string":
+		keys = []string{e.value.str}
+	case "list":
+		keys = e.value.list
+	default:
+		return fmt.Errorf("%s.%s: expected a key or a list of keys", e.section, e.key)
+	}
+	for _, key := range keys {
+		if _, err := keymap.ParseKey(key); err != nil {
+			return fmt.Errorf("%s.%s: %w", e.section, e.key, err)
+		}
+	}
+	c.bindings = append(c.bindings, binding{ctx, e.key, keys, e.value.line})
+	return nil
+}
+
+func (c *Config) setProfile(e entry) error {
+	name := strings.TrimPrefix(e.section, "profiles.")
+	if name == "" {
+		return fmt.Errorf("empty profile name in [%s]", e.section)
+	}
+	if e.key != "keep" && e.key != "remove" {
+		return fmt.Errorf("unknown key %q (valid keys: keep, remove)", qualify(e.section, e.key))
+	}
+	rules, err := expectList(e.value)
+	if err != nil {
+		return fmt.Errorf("%s.%s: %w", e.section, e.key, err)
+	}
+	for _, rule := range rules {
+		if err := metadata.ValidateRule(rule); err != nil {
+			return fmt.Errorf("%s.%s: %w", e.section, e.key, err)
+		}
+	}
+
+	index := -1
+	for i, p := range c.Profiles {
+		if p.Name == name {
+			index = i
+		}
+	}
+	if index < 0 {
+		c.Profiles = append(c.Profiles, metadata.Profile{Name: name})
+		index = len(c.Profiles) - 1
+	} else if !c.profiles[name] {
+		c.Profiles[index] = metadata.Profile{Name: name}
+	}
+	if c.profiles == nil {
+		c.profiles = make(map[string]bool)
+	}
+	c.profiles[name] = true
+	if e.key == "keep" {
+		c.Profiles[index].Keep = rules
+	} else {
+		c.Profiles[index].Remove = rules
+	}
+	return nil
+}
+
+func (c *Config) set(e entry) error {
+	if e.section == "keys" || strings.HasPrefix(e.section, "keys.") {
+		return c.setKey(e)
+	}
+	if strings.HasPrefix(e.section, "profiles.") {
+		return c.setProfile(e)
+	}
+
+	name := qualify(e.section, e.key)
+	set, ok := keys[name]
+	if ok {
+		if err := set(c, e.value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	}
+
+	var valid []string
+	for key := range keys {
+		section, k, found := strings.Cut(key, ".")
+		if !found {
+			section, k = "", key
+		}
+		if section == e.section {
+			valid = append(valid, k)
+		}
+	}
+	if len(valid) == 0 {
+		return fmt.Errorf("unknown section [%s]", e.section)
+	}
+	sort.Strings(valid)
+	return fmt.Errorf("unknown key %q (valid keys: %s)", name, strings.Join(valid, ", "))
+}
+
+func (c *Config) Apply() {
+	metadata.DefaultVersion = byte(c.TagVersion)
+	metadata.PreferUTF16 = c.Encoding == "utf-16"
+	metadata.Backup = metadata.BackupPolicy{Mode: c.Backup.Policy, Dir: c.Backup.Dir}
+	files.Extensions = c.Extensions
+	metadata.Profiles = c.Profiles
+	textops.SmallWords = c.SmallWords
+	library.IndexPath = ""
+	if c.Index {
+		library.IndexPath = CachePath("index.gob")
+	}
+	theme.SetAvailable(c.Themes)
+	if t, ok := theme.Lookup(c.Theme); ok {
+		theme.Set(t)
+	}
+}
+
+func expectString(v value) (string, error) {
+	if v.kind != "string" {
+		return "", fmt.Errorf("expected a quoted string")
+	}
+	return v.str, nil
+}
+
+func expectList(v value) ([]string, error) {
+	if v.kind != "list" {
+		return nil, fmt.Errorf("expected a list of strings")
+	}
+	return v.list, nil
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+func setStartDir(c *Config, v value) error {
+	s, err := expectString(v)
+	if err != nil {
+		return err
+	}
+	dir, err := expandHome(s)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	c.StartDir = dir
+	return nil
+}
+
+func setFormFields(c *Config, v value) error {
+	list, err := expectList(v)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return fmt.Errorf("at least one field is required")
+	}
+	seen := make(map[string]bool)
+	for _, name := range list {
+		if name != "cover" && !metadata.IsField(name) {
+			return fmt.Errorf("unknown field %q (valid fields: %s, cover)", name, strings.Join(metadata.Fields(), ", "))
+		}
+		if seen[name] {
+			return fmt.Errorf("field %q listed twice", name)
+		}
+		seen[name] = true
+	}
+	c.FormFields = list
+	return nil
+}
+
+func setTagVersion(c *Config, v value) error {
+	if v.kind != "int" || (v.num != 3 && v.num != 4) {
+		return fmt.Errorf("must be 3 or 4")
+	}
+	c.TagVersion = v.num
+	return nil
+}
+
+func setEncoding(c *Config, v value) error {
+	s, err := expectString(v)
+	if err != nil {
+		return err
+	}
+	s = strings.ToLower(s)
+	if s != "utf-8" && s != "utf-16" {
+		return fmt.Errorf(`must be "utf-8" or "utf-16"`)
+	}
+	c.Encoding = s
+	return nil
+}
+
+func setExtensions(c *Config, v value) error {
+	list, err := expectList(v)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return fmt.Errorf("at least one extension is required")
+	}
+	extensions := make([]string, len(list))
+	for i, ext := range list {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if len(ext) < 2 || strings.ContainsAny(ext[1:], `./\`) {
+			return fmt.Errorf("invalid extension %q", list[i])
+		}
+		extensions[i] = ext
+	}
+	c.Extensions = extensions
+	return nil
+}
+
+func setBackupPolicy(c *Config, v value) error {
+	s, err := expectString(v)
+	if err != nil {
+		return err
+	}
+	switch s {
+	case metadata.BackupNone, metadata.BackupOnce, metadata.BackupAlways:
+		c.Backup.Policy = s
+		return nil
+	}
+	return fmt.Errorf(`must be "none", "once" or "always"`)
+}
+
+func setBackupDir(c *Config, v value) error {
+	s, err := expectString(v)
+	if err != nil {
+		return err
+	}
+	dir, err := expandHome(s)
+	if err != nil {
+		return err
+	}
+	c.Backup.Dir = dir
+	return nil
+}
+
+func setTheme(c *Config, v value) error {
+	s, err := expectString(v)
+	if err != nil {
+		return err
+	}
+	c.Theme = s
+	c.themeLine = v.line
+	return nil
+}
+
+func expectBool(v value) (bool, error) {
+	if v.kind != "bool" {
+		return false, fmt.Errorf("must be true or false")
+	}
+	return v.str == "true", nil
+}
+
+func setVim(c *Config, v value) (err error) {
+	c.Vim, err = expectBool(v)
+	return err
+}
+
+func setConfirmSave(c *Config, v value) (err error) {
+	c.ConfirmSave, err = expectBool(v)
+	return err
+}
+
+func hookSetter(event hooks.Event) setter {
+	return func(c *Config, v value) error {
+		switch v.kind {
+		case "string":
+			c.Hooks[event] = []string{v.str}
+		case "list":
+			c.Hooks[event] = v.list
+		default:
+			return fmt.Errorf("expected a command string or a list of commands")
+		}
+		return nil
+	}
+}
+
+func setIndex(c *Config, v value) (err error) {
+	c.Index, err = expectBool(v)
+	return err
+}
+
+func setSmallWords(c *Config, v value) error {
+	list, err := expectList(v)
+	if err != nil {
+		return err
+	}
+	words := make([]string, len(list))
+	for i, word := range list {
+		if word == "" || strings.ContainsFunc(word, unicode.IsSpace) {
+			return fmt.Errorf("small word %q must be a single word", word)
+		}
+		words[i] = strings.ToLower(word)
+	}
+	c.SmallWords = words
+	return nil
+}
+
+func setBrowserSort(c *Config, v value) error {
+	s, err := expectString(v)
+	if err != nil {
+		return err
+	}
+	for _, key := range files.SortKeys {
+		if s == key {
+			c.Browser.Sort = s
+			return nil
+		}
+	}
+	if !metadata.IsField(s) {
+		return fmt.Errorf("unknown sort key %q (valid keys: %s, %s)", s, strings.Join(files.SortKeys, ", "), strings.Join(metadata.Fields(), ", "))
+	}
+	c.Browser.Sort = s
+	return nil
+}
+
+func setBrowserDescending(c *Config, v value) (err error) {
+	c.Browser.Descending, err = expectBool(v)
+	return err
+}
+
+func setBrowserHidden(c *Config, v value) (err error) {
+	c.Browser.Hidden, err = expectBool(v)
+	return err
+}
+
+func setBrowserColumns(c *Config, v value) error {
+	list, err := expectList(v)
+	if err != nil {
+		return err
+	}
+	for _, column := range list {
+		known := false
+		for _, name := range files.ColumnNames {
+			known = known || column == name
+		}
+		if !known {
+			return fmt.Errorf("unknown column %q (valid columns: %s)", column, strings.Join(files.ColumnNames, ", "))
+		}
+	}
+	c.Browser.Columns = list
+	return nil
+}
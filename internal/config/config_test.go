@@ -0,0 +1,395 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+
+	"id3v2-tui/internal/hooks"
+	"id3v2-tui/internal/keymap"
+	"id3v2-tui/internal/metadata"
+	"id3v2-tui/internal/theme"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "missing.toml"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.TagVersion != 4 || len(cfg.FormFields) != len(DefaultFormFields) || cfg.Extensions[0] != ".mp3" || !cfg.ConfirmSave {
+		t.Errorf("expected defaults, got %+v", cfg)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	startDir := t.TempDir()
+	path := writeConfig(t, `# id3v2-tui settings
+start_dir = "`+startDir+`"
+
+[form]
+fields = [
+  "artist", # shown first
+  "title",
+  "year",
+]
+
+[tags]
+version = 3
+encoding = "UTF-16"
+
+[files]
+extensions = ["mp3", ".MP2"]
+
+[backup]
+policy = "once"
+dir = '/tmp/backups'
+
+[ui]
+vim = true
+confirm_save = false
+
+[hooks]
+save = "mpc update"
+batch = ["sync-device", "echo '#done'"]
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.StartDir != startDir {
+		t.Errorf("unexpected start dir %q", cfg.StartDir)
+	}
+	if strings.Join(cfg.FormFields, ",") != "artist,title,year" {
+		t.Errorf("unexpected form fields %v", cfg.FormFields)
+	}
+	if cfg.TagVersion != 3 || cfg.Encoding != "utf-16" {
+		t.Errorf("unexpected tag settings %d %q", cfg.TagVersion, cfg.Encoding)
+	}
+	if strings.Join(cfg.Extensions, ",") != ".mp3,.mp2" {
+		t.Errorf("unexpected extensions %v", cfg.Extensions)
+	}
+	if cfg.Backup.Policy != "once" || cfg.Backup.Dir != "/tmp/backups" {
+		t.Errorf("unexpected backup %+v", cfg.Backup)
+	}
+	if cfg.Hooks[hooks.EventSave][0] != "mpc update" || cfg.Hooks[hooks.EventBatch][1] != "echo '#done'" {
+		t.Errorf("unexpected hooks %v", cfg.Hooks)
+	}
+	if !cfg.Vim || cfg.ConfirmSave {
+		t.Errorf("unexpected ui settings vim=%v confirm_save=%v", cfg.Vim, cfg.ConfirmSave)
+	}
+}
+
+func TestLoadValidationErrors(t *testing.T) {
+	path := writeConfig(t, `start_dir = "/nonexistent/music"
+
+[form]
+feilds = ["title"]
+
+[tags]
+version = 2
+encoding = latin1
+
+[colors]
+primary = "#fff"
+`)
+
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.Contains(err.Error(), "line 8") {
+		t.Fatalf("expected parse error for unquoted value, got %v", err)
+	}
+
+	path = writeConfig(t, `start_dir = "/nonexistent/music"
+
+[form]
+feilds = ["title"]
+fields = ["title", "genre"]
+
+[tags]
+version = 2
+
+[colors]
+primary = "#fff"
+`)
+
+	_, err = Load(path)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		":1: start_dir:",
+		`:4: unknown key "form.feilds" (valid keys: fields)`,
+		`:5: form.fields: unknown field "genre"`,
+		":8: tags.version: must be 3 or 4",
+		":11: unknown section [colors]",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in error:\n%v", want, err)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"[form", "unterminated section header"},
+		{"fields", "expected key = value"},
+		{"a = \"open", "unterminated string"},
+		{"a = [\"x\" \"y\"]", "expected , between list items"},
+		{"a = [1, 2]", "list items must be quoted strings"},
+		{"a = 1\na = 2", "a already set on line 1"},
+		{"[x]\n[x]", "section [x] already defined on line 1"},
+	}
+
+	for _, tt := range tests {
+		_, err := parse(strings.NewReader(tt.input))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parse(%q) error = %v, want %q", tt.input, err, tt.want)
+		}
+	}
+}
+
+func TestPath(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	if Path() != "/xdg/id3v2-tui/config.toml" {
+		t.Errorf("unexpected path %q", Path())
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("HOME", "/home/user")
+	if Path() != "/home/user/.config/id3v2-tui/config.toml" {
+		t.Errorf("unexpected path %q", Path())
+	}
+
+	t.Setenv("XDG_STATE_HOME", "")
+	if path := StatePath("views.json"); path != "/home/user/.local/state/id3v2-tui/views.json" {
+		t.Errorf("unexpected state path %q", path)
+	}
+	t.Setenv("XDG_CACHE_HOME", "/cache")
+	if path := CachePath("index.gob"); path != "/cache/id3v2-tui/index.gob" {
+		t.Errorf("unexpected cache path %q", path)
+	}
+}
+
+func TestLoadThemes(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "themes"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	err := os.WriteFile(filepath.Join(dir, "themes", "solarized.toml"), []byte(`
+background = "#002b36"
+primary = "#268bd2"
+text = "white"
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(path, []byte("[ui]\ntheme = \"solarized\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(cfg.Themes) != 4 || cfg.Theme != "solarized" {
+		t.Fatalf("unexpected themes %v / %q", cfg.Themes, cfg.Theme)
+	}
+	solarized := cfg.Themes[3]
+	if solarized.Background != tcell.NewHexColor(0x002B36) || solarized.Text != tcell.ColorWhite {
+		t.Errorf("unexpected colors %+v", solarized)
+	}
+	if solarized.Error != theme.Dark.Error {
+		t.Error("unset colors should fall back to the dark theme")
+	}
+}
+
+func TestLoadThemeErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bad.toml")
+
+	os.WriteFile(path, []byte("primary = \"notacolor\"\n"), 0o644)
+	if _, err := LoadTheme(path); err == nil || !strings.Contains(err.Error(), `bad.toml:1: primary: invalid color "notacolor"`) {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	os.WriteFile(path, []byte("\nborder = \"red\"\n"), 0o644)
+	if _, err := LoadTheme(path); err == nil || !strings.Contains(err.Error(), `bad.toml:2: unknown key "border"`) {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	config := filepath.Join(dir, "config.toml")
+	os.WriteFile(config, []byte("[ui]\ntheme = \"missing\"\n"), 0o644)
+	if _, err := Load(config); err == nil || !strings.Contains(err.Error(), `:2: ui.theme: unknown theme "missing" (available: dark, light, high-contrast)`) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	path := writeConfig(t, `[keys]
+save = ["ctrl+w", "F2"]
+quit = "ctrl+q"
+rename = []
+
+[keys.list]
+quit = "x"
+help = "q"
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if keys := cfg.Keymap.Keys(keymap.ContextGlobal, "save"); strings.Join(keys, ",") != "ctrl+w,f2" {
+		t.Errorf("unexpected save keys %v", keys)
+	}
+	if keys := cfg.Keymap.Keys(keymap.ContextGlobal, "rename"); len(keys) != 0 {
+		t.Errorf("rename should be unbound, got %v", keys)
+	}
+	if cfg.Keymap.Hint("help", keymap.ContextList) != "q" || cfg.Keymap.Hint("quit", keymap.ContextList) != "x" {
+		t.Error("list bindings were not swapped")
+	}
+
+	path = writeConfig(t, `[keys]
+save = "ctrl+q"
+launch = "f3"
+quit = "hyper+q"
+
+[keys.modal]
+save = "f2"
+
+[keys.browser]
+quit = "x"
+`)
+	_, err = Load(path)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		`:2: keys: ctrl+q is already bound to "quit" in the global context`,
+		`:3: unknown action "launch" (valid actions: quit, help,`,
+		`:4: keys.quit: unknown key "hyper+q"`,
+		`:7: keys.modal: action "save" cannot be bound in the modal context`,
+		`:10: unknown section [keys.browser] (key sections are [keys], [keys.list], [keys.form], [keys.modal])`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in error:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoadProfiles(t *testing.T) {
+	path := writeConfig(t, `[profiles.minimal]
+keep = ["title", "artist"]
+
+[profiles.podcast]
+remove = ["PRIV", "COMM:iTunNORM", "cover"]
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	profiles := make(map[string]metadata.Profile)
+	for _, p := range cfg.Profiles {
+		profiles[p.Name] = p
+	}
+	if minimal := profiles["minimal"]; strings.Join(minimal.Keep, ",") != "title,artist" || len(minimal.Remove) != 0 {
+		t.Errorf("minimal should be replaced, got %+v", minimal)
+	}
+	if _, ok := profiles["clean"]; !ok {
+		t.Error("built-in profiles should remain available")
+	}
+	if podcast := profiles["podcast"]; len(podcast.Remove) != 3 {
+		t.Errorf("unexpected podcast profile %+v", podcast)
+	}
+
+	path = writeConfig(t, `[profiles.bad]
+keep = ["titel"]
+drop = ["PRIV"]
+`)
+	_, err = Load(path)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		`:2: profiles.bad.keep: "titel" is neither a field`,
+		`:3: unknown key "profiles.bad.drop" (valid keys: keep, remove)`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in error:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoadBrowser(t *testing.T) {
+	path := writeConfig(t, `[browser]
+sort = "year"
+descending = true
+hidden = true
+columns = ["size", "mtime"]
+
+[cache]
+index = false
+
+[text]
+small_words = ["De", "la", "y"]
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if b := cfg.Browser; b.Sort != "year" || !b.Descending || !b.Hidden || !b.Shows("mtime") {
+		t.Errorf("unexpected browser view %+v", b)
+	}
+	if cfg.Index || !Default().Index {
+		t.Error("the index should be on by default and disabled by [cache]")
+	}
+	if strings.Join(cfg.SmallWords, ",") != "de,la,y" || len(Default().SmallWords) == 0 {
+		t.Errorf("unexpected small words %v", cfg.SmallWords)
+	}
+	if Default().Browser.Sort != "name" {
+		t.Error("the default sort should be by name")
+	}
+
+	path = writeConfig(t, `[browser]
+sort = "colour"
+columns = ["bitrate"]
+
+[text]
+small_words = ["of the"]
+`)
+	_, err = Load(path)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		`:2: browser.sort: unknown sort key "colour"`,
+		`:3: browser.columns: unknown column "bitrate" (valid columns: size, mtime)`,
+		`:6: text.small_words: small word "of the" must be a single word`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in error:\n%v", want, err)
+		}
+	}
+}
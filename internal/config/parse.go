@@ -0,0 +1,226 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type value struct {
+	str  string
+	num  int
+	list []string
+	kind string
+	line int
+}
+
+type entry struct {
+	section string
+	key     string
+	value   value
+}
+
+type ParseError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+func parse(r io.Reader) ([]entry, error) {
+	scanner := bufio.NewScanner(r)
+	var entries []entry
+	section := ""
+	seen := make(map[string]int)
+	sections := make(map[string]int)
+
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		start := lineNum
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, &ParseError{lineNum, fmt.Sprintf("unterminated section header %q", line)}
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if section == "" {
+				return nil, &ParseError{lineNum, "empty section name"}
+			}
+			if prev, ok := sections[section]; ok {
+				return nil, &ParseError{lineNum, fmt.Sprintf("section [%s] already defined on line %d", section, prev)}
+			}
+			sections[section] = lineNum
+			continue
+		}
+
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, &ParseError{lineNum, fmt.Sprintf("expected key = value, got %q", line)}
+		}
+		key = strings.TrimSpace(key)
+		raw = strings.TrimSpace(raw)
+		if key == "" {
+			return nil, &ParseError{lineNum, "missing key before ="}
+		}
+		if strings.HasPrefix(key, `"`) {
+			unquoted, err := strconv.Unquote(key)
+			if err != nil {
+				return nil, &ParseError{lineNum, fmt.Sprintf("invalid quoted key %s", key)}
+			}
+			key = unquoted
+		}
+
+		for strings.HasPrefix(raw, "[") && !balanced(raw) && scanner.Scan() {
+			lineNum++
+			raw += " " + strings.TrimSpace(stripComment(scanner.Text()))
+		}
+
+		v, err := parseValue(raw)
+		if err != nil {
+			return nil, &ParseError{start, fmt.Sprintf("%s: %s", qualify(section, key), err)}
+		}
+		v.line = start
+
+		name := qualify(section, key)
+		if prev, ok := seen[name]; ok {
+			return nil, &ParseError{start, fmt.Sprintf("%s already set on line %d", name, prev)}
+		}
+		seen[name] = start
+		entries = append(entries, entry{section, key, v})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func qualify(section, key string) string {
+	if section == "" {
+		return key
+	}
+	return section + "." + key
+}
+
+func stripComment(line string) string {
+	inString := byte(0)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case inString != 0 && c == '\\' && inString == '"':
+			i++
+		case inString != 0 && c == inString:
+			inString = 0
+		case inString == 0 && (c == '"' || c == '\''):
+			inString = c
+		case inString == 0 && c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func balanced(raw string) bool {
+	depth := 0
+	inString := byte(0)
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case inString != 0 && c == '\\' && inString == '"':
+			i++
+		case inString != 0 && c == inString:
+			inString = 0
+		case inString == 0 && (c == '"' || c == '\''):
+			inString = c
+		case inString == 0 && c == '[':
+			depth++
+		case inString == 0 && c == ']':
+			depth--
+		}
+	}
+	return depth == 0
+}
+
+func parseValue(raw string) (value, error) {
+	switch {
+	case raw == "":
+		return value{}, fmt.Errorf("missing value")
+	case raw == "true" || raw == "false":
+		return value{kind: "bool", str: raw}, nil
+	case strings.HasPrefix(raw, "["):
+		if !strings.HasSuffix(raw, "]") {
+			return value{}, fmt.Errorf("unterminated list")
+		}
+		items, err := parseList(strings.TrimSpace(raw[1 : len(raw)-1]))
+		if err != nil {
+			return value{}, err
+		}
+		return value{kind: "list", list: items}, nil
+	case strings.HasPrefix(raw, `"`) || strings.HasPrefix(raw, "'"):
+		s, rest, err := parseString(raw)
+		if err != nil {
+			return value{}, err
+		}
+		if strings.TrimSpace(rest) != "" {
+			return value{}, fmt.Errorf("unexpected %q after string", rest)
+		}
+		return value{kind: "string", str: s}, nil
+	}
+
+	if n, err := strconv.Atoi(raw); err == nil {
+		return value{kind: "int", num: n, str: raw}, nil
+	}
+	return value{}, fmt.Errorf("invalid value %q (strings must be quoted)", raw)
+}
+
+func parseString(raw string) (string, string, error) {
+	quote := raw[0]
+	for i := 1; i < len(raw); i++ {
+		switch {
+		case quote == '"' && raw[i] == '\\':
+			i++
+		case raw[i] == quote:
+			if quote == '\'' {
+				return raw[1:i], raw[i+1:], nil
+			}
+			s, err := strconv.Unquote(raw[:i+1])
+			if err != nil {
+				return "", "", fmt.Errorf("invalid string %s", raw[:i+1])
+			}
+			return s, raw[i+1:], nil
+		}
+	}
+	return "", "", fmt.Errorf("unterminated string %s", raw)
+}
+
+func parseList(raw string) ([]string, error) {
+	items := []string{}
+	for raw != "" {
+		if raw[0] != '"' && raw[0] != '\'' {
+			return nil, fmt.Errorf("list items must be quoted strings")
+		}
+		s, rest, err := parseString(raw)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, s)
+
+		rest = strings.TrimSpace(rest)
+		if rest == "" {
+			break
+		}
+		if rest[0] != ',' {
+			return nil, fmt.Errorf("expected , between list items")
+		}
+		raw = strings.TrimSpace(rest[1:])
+	}
+	return items, nil
+}
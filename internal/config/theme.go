@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+
+	"id3v2-tui/internal/theme"
+)
+
+func themeColors(t *theme.Theme) map[string]*tcell.Color {
+	return map[string]*tcell.Color{
+		"background": &t.Background,
+		"primary":    &t.Primary,
+		"secondary":  &t.Secondary,
+		"text":       &t.Text,
+		"text_dim":   &t.TextDim,
+		"error":      &t.Error,
+	}
+}
+
+func parseColor(s string) (tcell.Color, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "default" {
+		return tcell.ColorDefault, nil
+	}
+	if c := tcell.GetColor(s); c != tcell.ColorDefault {
+		return c, nil
+	}
+	return tcell.ColorDefault, fmt.Errorf("invalid color %q (use #rrggbb or a color name)", s)
+}
+
+func LoadTheme(path string) (theme.Theme, error) {
+	t := theme.Dark
+	t.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	f, err := os.Open(path)
+	if err != nil {
+		return t, err
+	}
+	defer f.Close()
+
+	entries, err := parse(f)
+	if err != nil {
+		return t, fmt.Errorf("%s: %w", path, err)
+	}
+
+	colors := themeColors(&t)
+	for _, e := range entries {
+		name := qualify(e.section, e.key)
+		if name == "name" {
+			if e.value.kind != "string" || e.value.str == "" {
+				return t, fmt.Errorf("%s:%d: name: expected a quoted string", path, e.value.line)
+			}
+			t.Name = e.value.str
+			continue
+		}
+
+		color, ok := colors[name]
+		if !ok {
+			valid := []string{"name"}
+			for key := range colors {
+				valid = append(valid, key)
+			}
+			sort.Strings(valid)
+			return t, fmt.Errorf("%s:%d: unknown key %q (valid keys: %s)", path, e.value.line, name, strings.Join(valid, ", "))
+		}
+		if e.value.kind != "string" {
+			return t, fmt.Errorf("%s:%d: %s: expected a quoted color", path, e.value.line, name)
+		}
+		if *color, err = parseColor(e.value.str); err != nil {
+			return t, fmt.Errorf("%s:%d: %s: %w", path, e.value.line, name, err)
+		}
+	}
+	return t, nil
+}
+
+func LoadThemes(dir string) ([]theme.Theme, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.toml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var themes []theme.Theme
+	for _, path := range paths {
+		t, err := LoadTheme(path)
+		if err != nil {
+			return nil, err
+		}
+		themes = append(themes, t)
+	}
+	return themes, nil
+}
@@ -0,0 +1,147 @@
+package exchange
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"id3v2-tui/internal/metadata"
+)
+
+const pathColumn = "path"
+
+func Delimiter(path string) rune {
+	if strings.EqualFold(filepath.Ext(path), ".tsv") {
+		return '\t'
+	}
+	return ','
+}
+
+func WriteCSV(w io.Writer, paths []string, comma rune) []FileError {
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+
+	fields := metadata.Fields()
+	var errs []FileError
+	if err := writer.Write(append([]string{pathColumn}, fields...)); err != nil {
+		return []FileError{{"(output)", err}}
+	}
+
+	sorted := append([]string(nil), paths...)
+	sort.Strings(sorted)
+	for _, path := range sorted {
+		meta, err := metadata.Read(path)
+		if err != nil {
+			errs = append(errs, FileError{path, err})
+			continue
+		}
+		row := []string{filepath.ToSlash(path)}
+		for _, name := range fields {
+			value, _ := meta.Get(name)
+			row = append(row, value)
+		}
+		if err := writer.Write(row); err != nil {
+			return append(errs, FileError{"(output)", err})
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		errs = append(errs, FileError{"(output)", err})
+	}
+	return errs
+}
+
+func PlanCSV(r io.Reader, comma rune) ([]*Change, []FileError, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = comma
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, errors.New("empty spreadsheet")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid header: %w", err)
+	}
+
+	pathIndex := -1
+	columns := make([]string, len(header))
+	var unknown []string
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		columns[i] = name
+		switch {
+		case name == pathColumn:
+			pathIndex = i
+		case !metadata.IsField(name):
+			unknown = append(unknown, header[i])
+		}
+	}
+	if pathIndex < 0 {
+		return nil, nil, fmt.Errorf("missing %q column", pathColumn)
+	}
+	if len(unknown) > 0 {
+		return nil, nil, fmt.Errorf("unknown columns %v, valid columns are %v", unknown, metadata.Fields())
+	}
+
+	var changes []*Change
+	var errs []FileError
+	seen := make(map[string]int)
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				errs = append(errs, FileError{fmt.Sprintf("line %d", parseErr.Line), parseErr.Err})
+				if !errors.Is(parseErr.Err, csv.ErrFieldCount) {
+					return changes, errs, nil
+				}
+				continue
+			}
+			return changes, errs, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		path := filepath.FromSlash(strings.TrimSpace(row[pathIndex]))
+		if path == "" {
+			errs = append(errs, FileError{fmt.Sprintf("line %d", line), errors.New("missing path")})
+			continue
+		}
+		if first, ok := seen[path]; ok {
+			errs = append(errs, FileError{path, fmt.Errorf("duplicate row on line %d, first seen on line %d", line, first)})
+			continue
+		}
+		seen[path] = line
+
+		if _, err := os.Stat(path); err != nil {
+			errs = append(errs, FileError{path, err})
+			continue
+		}
+		before, err := metadata.Read(path)
+		if err != nil {
+			errs = append(errs, FileError{path, err})
+			continue
+		}
+
+		after := before.Clone()
+		for i, name := range columns {
+			if i != pathIndex {
+				after.Set(name, row[i])
+			}
+		}
+		if before.Diff(after) == "" {
+			continue
+		}
+		changes = append(changes, &Change{Path: path, Before: before, After: after})
+	}
+
+	return changes, errs, nil
+}
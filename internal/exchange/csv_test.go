@@ -0,0 +1,92 @@
+package exchange
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"id3v2-tui/internal/metadata"
+)
+
+func TestCSVRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := copyTestFile(t, dir, "a.mp3")
+	if err := metadata.Save(path, &metadata.Metadata{TrackName: "Song, with comma", Artist: "Old"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if errs := WriteCSV(&buf, []string{path}, ','); len(errs) > 0 {
+		t.Fatalf("WriteCSV failed: %v", errs)
+	}
+	if !strings.HasPrefix(buf.String(), "path,title,artist,") {
+		t.Errorf("unexpected header in %q", buf.String())
+	}
+	if !strings.Contains(buf.String(), `"Song, with comma"`) {
+		t.Errorf("expected quoted title in %q", buf.String())
+	}
+
+	edited := strings.Replace(buf.String(), ",Old,", ",New,", 1)
+	changes, errs, err := PlanCSV(strings.NewReader(edited), ',')
+	if err != nil || len(errs) > 0 {
+		t.Fatalf("PlanCSV failed: %v %v", err, errs)
+	}
+	if len(changes) != 1 || changes[0].Diff() != "Artist: Old → New" {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+
+	if _, errs := Apply(changes); len(errs) > 0 {
+		t.Fatalf("Apply failed: %v", errs)
+	}
+	meta, _ := metadata.Read(path)
+	if meta.Artist != "New" || meta.TrackName != "Song, with comma" {
+		t.Errorf("unexpected metadata %+v", meta)
+	}
+}
+
+func TestPlanCSVTSV(t *testing.T) {
+	dir := t.TempDir()
+	path := copyTestFile(t, dir, "a.mp3")
+
+	input := "path\ttitle\n" + filepath.ToSlash(path) + "\tTabbed\n"
+	changes, errs, err := PlanCSV(strings.NewReader(input), Delimiter("tags.tsv"))
+	if err != nil || len(errs) > 0 {
+		t.Fatalf("PlanCSV failed: %v %v", err, errs)
+	}
+	if len(changes) != 1 || changes[0].After.TrackName != "Tabbed" {
+		t.Errorf("unexpected changes %+v", changes)
+	}
+}
+
+func TestPlanCSVErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.ToSlash(copyTestFile(t, dir, "a.mp3"))
+
+	if _, _, err := PlanCSV(strings.NewReader("title,bogus\n"), ','); err == nil {
+		t.Error("expected error for missing path column")
+	}
+	if _, _, err := PlanCSV(strings.NewReader("path,bogus\n"), ','); err == nil || !strings.Contains(err.Error(), "bogus") {
+		t.Errorf("expected unknown column error, got %v", err)
+	}
+	if _, _, err := PlanCSV(strings.NewReader(""), ','); err == nil {
+		t.Error("expected error for empty input")
+	}
+
+	input := "path,title\n" +
+		dir + "/missing.mp3,x\n" +
+		path + ",one,extra\n" +
+		path + ",ok\n" +
+		path + ",dup\n" +
+		path + ",\"bad\"quote\n"
+	changes, errs, err := PlanCSV(strings.NewReader(input), ',')
+	if err != nil {
+		t.Fatalf("PlanCSV failed: %v", err)
+	}
+	if len(changes) != 1 {
+		t.Errorf("expected 1 change, got %d", len(changes))
+	}
+	if len(errs) != 4 {
+		t.Errorf("expected 4 errors (missing, field count, duplicate, quote), got %v", errs)
+	}
+}
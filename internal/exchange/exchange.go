@@ -0,0 +1,71 @@
+package exchange
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"id3v2-tui/internal/files"
+	"id3v2-tui/internal/metadata"
+)
+
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e FileError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Err)
+}
+
+type Change struct {
+	Path   string
+	Before *metadata.Metadata
+	After  *metadata.Metadata
+}
+
+func (c *Change) Diff() string {
+	return c.Before.Diff(c.After)
+}
+
+func Expand(paths []string) ([]string, []FileError) {
+	var result []string
+	var errs []FileError
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			errs = append(errs, FileError{path, err})
+			continue
+		}
+		if !info.IsDir() {
+			result = append(result, filepath.Clean(path))
+			continue
+		}
+		found, err := files.ListAudioFiles(path, true)
+		if err != nil {
+			errs = append(errs, FileError{path, err})
+			continue
+		}
+		result = append(result, found...)
+	}
+	sort.Strings(result)
+	return result, errs
+}
+
+func Apply(changes []*Change) (int, []FileError) {
+	saved := 0
+	var errs []FileError
+	for _, c := range changes {
+		if err := save(c); err != nil {
+			errs = append(errs, FileError{c.Path, err})
+			continue
+		}
+		saved++
+	}
+	return saved, errs
+}
+
+func save(c *Change) error {
+	return metadata.Save(c.Path, c.After)
+}
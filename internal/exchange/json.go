@@ -0,0 +1,168 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"id3v2-tui/internal/metadata"
+)
+
+const jsonVersion = 1
+
+type Document struct {
+	Version int         `json:"version"`
+	Files   []FileEntry `json:"files"`
+}
+
+type FileEntry struct {
+	Path      string            `json:"path"`
+	Tags      map[string]string `json:"tags"`
+	Pictures  []PictureEntry    `json:"pictures,omitempty"`
+	Technical *TechnicalEntry   `json:"technical,omitempty"`
+}
+
+type PictureEntry struct {
+	Type        string `json:"type"`
+	MimeType    string `json:"mime_type"`
+	Description string `json:"description,omitempty"`
+	Size        int    `json:"size"`
+	SHA256      string `json:"sha256"`
+}
+
+type TechnicalEntry struct {
+	FileSize   int64   `json:"file_size"`
+	TagVersion string  `json:"tag_version,omitempty"`
+	TagSize    int64   `json:"tag_size"`
+	Bitrate    int     `json:"bitrate_kbps,omitempty"`
+	SampleRate int     `json:"sample_rate,omitempty"`
+	Channels   int     `json:"channels,omitempty"`
+	VBR        bool    `json:"vbr"`
+	Duration   float64 `json:"duration_seconds"`
+}
+
+func entry(path string) (FileEntry, error) {
+	meta, err := metadata.Read(path)
+	if err != nil {
+		return FileEntry{}, err
+	}
+	pictures, err := metadata.ReadPictures(path)
+	if err != nil {
+		return FileEntry{}, err
+	}
+	info, err := metadata.ReadTechInfo(path)
+	if err != nil {
+		return FileEntry{}, err
+	}
+
+	e := FileEntry{
+		Path: filepath.ToSlash(path),
+		Tags: make(map[string]string),
+		Technical: &TechnicalEntry{
+			FileSize:   info.FileSize,
+			TagSize:    info.TagSize,
+			Bitrate:    info.Bitrate,
+			SampleRate: info.SampleRate,
+			Channels:   info.Channels,
+			VBR:        info.VBR,
+			Duration:   float64(info.Duration.Milliseconds()) / 1000,
+		},
+	}
+	if info.TagVersion > 0 {
+		e.Technical.TagVersion = fmt.Sprintf("2.%d", info.TagVersion)
+	}
+	for _, name := range metadata.Fields() {
+		e.Tags[name], _ = meta.Get(name)
+	}
+	for _, p := range pictures {
+		e.Pictures = append(e.Pictures, PictureEntry(p))
+	}
+	return e, nil
+}
+
+func Export(paths []string) (*Document, []FileError) {
+	doc := &Document{Version: jsonVersion, Files: []FileEntry{}}
+	var errs []FileError
+
+	sorted := append([]string(nil), paths...)
+	sort.Strings(sorted)
+	for _, path := range sorted {
+		e, err := entry(path)
+		if err != nil {
+			errs = append(errs, FileError{path, err})
+			continue
+		}
+		doc.Files = append(doc.Files, e)
+	}
+	return doc, errs
+}
+
+func WriteJSON(w io.Writer, doc *Document) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(doc)
+}
+
+func ReadJSON(r io.Reader) (*Document, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	var doc Document
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON document: %w", err)
+	}
+	if doc.Version != jsonVersion {
+		return nil, fmt.Errorf("unsupported document version %d", doc.Version)
+	}
+	return &doc, nil
+}
+
+func PlanJSON(doc *Document) ([]*Change, []FileError) {
+	var changes []*Change
+	var errs []FileError
+
+	for _, e := range doc.Files {
+		path := filepath.FromSlash(e.Path)
+		if e.Path == "" {
+			errs = append(errs, FileError{"(entry)", fmt.Errorf("missing path")})
+			continue
+		}
+
+		var unknown []string
+		for name := range e.Tags {
+			if !metadata.IsField(name) {
+				unknown = append(unknown, name)
+			}
+		}
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			errs = append(errs, FileError{path, fmt.Errorf("unknown fields %v, valid fields are %v", unknown, metadata.Fields())})
+			continue
+		}
+
+		if _, err := os.Stat(path); err != nil {
+			errs = append(errs, FileError{path, err})
+			continue
+		}
+		before, err := metadata.Read(path)
+		if err != nil {
+			errs = append(errs, FileError{path, err})
+			continue
+		}
+
+		after := before.Clone()
+		for name, value := range e.Tags {
+			after.Set(name, value)
+		}
+		if before.Diff(after) == "" {
+			continue
+		}
+		changes = append(changes, &Change{Path: path, Before: before, After: after})
+	}
+
+	return changes, errs
+}
@@ -0,0 +1,132 @@
+package exchange
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"id3v2-tui/internal/metadata"
+)
+
+const testFile = "../../test/test.mp3"
+
+func copyTestFile(t *testing.T, dir, name string) string {
+	data, err := os.ReadFile(testFile)
+	if os.IsNotExist(err) {
+		t.Skip("test.mp3 not found")
+	}
+	if err != nil {
+		t.Fatalf("failed to read test file: %v", err)
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+	return path
+}
+
+func TestExportDeterministic(t *testing.T) {
+	dir := t.TempDir()
+	b := copyTestFile(t, dir, "b.mp3")
+	a := copyTestFile(t, dir, "a.mp3")
+	if err := metadata.Save(a, &metadata.Metadata{TrackName: "A", Artist: "X"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var first, second bytes.Buffer
+	doc, errs := Export([]string{b, a})
+	if len(errs) > 0 {
+		t.Fatalf("Export failed: %v", errs)
+	}
+	if err := WriteJSON(&first, doc); err != nil {
+		t.Fatal(err)
+	}
+	doc, _ = Export([]string{a, b})
+	if err := WriteJSON(&second, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if first.String() != second.String() {
+		t.Error("expected identical output regardless of input order")
+	}
+	if doc.Files[0].Path != filepath.ToSlash(a) {
+		t.Errorf("expected files sorted by path, got %s first", doc.Files[0].Path)
+	}
+	if len(doc.Files[0].Tags) != len(metadata.Fields()) {
+		t.Errorf("expected every field in export, got %v", doc.Files[0].Tags)
+	}
+	if doc.Files[0].Technical == nil || doc.Files[0].Technical.Duration == 0 {
+		t.Errorf("expected technical info, got %+v", doc.Files[0].Technical)
+	}
+}
+
+func TestReadJSONErrors(t *testing.T) {
+	tests := []string{
+		`not json`,
+		`{"version": 2, "files": []}`,
+		`{"version": 1, "files": [], "extra": true}`,
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			if _, err := ReadJSON(strings.NewReader(input)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestPlanJSON(t *testing.T) {
+	dir := t.TempDir()
+	path := copyTestFile(t, dir, "a.mp3")
+	if err := metadata.Save(path, &metadata.Metadata{TrackName: "Old", Comment: "ripper"}); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := &Document{Version: 1, Files: []FileEntry{
+		{Path: path, Tags: map[string]string{"title": "New", "comment": ""}},
+		{Path: filepath.Join(dir, "missing.mp3"), Tags: map[string]string{"title": "x"}},
+		{Path: path, Tags: map[string]string{"bogus": "x"}},
+	}}
+
+	changes, errs := PlanJSON(doc)
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %d", len(changes))
+	}
+	if len(errs) != 2 {
+		t.Errorf("expected 2 errors, got %v", errs)
+	}
+
+	saved, applyErrs := Apply(changes)
+	if saved != 1 || len(applyErrs) > 0 {
+		t.Fatalf("Apply failed: %v", applyErrs)
+	}
+
+	meta, err := metadata.Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.TrackName != "New" || meta.Comment != "" {
+		t.Errorf("unexpected metadata after apply %+v", meta)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	dir := t.TempDir()
+	copyTestFile(t, dir, "a.mp3")
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	copyTestFile(t, filepath.Join(dir, "sub"), "b.mp3")
+
+	paths, errs := Expand([]string{dir, filepath.Join(dir, "nope.mp3")})
+	if len(paths) != 2 {
+		t.Errorf("expected 2 paths, got %v", paths)
+	}
+	if len(errs) != 1 {
+		t.Errorf("expected 1 error, got %v", errs)
+	}
+}
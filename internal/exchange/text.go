@@ -0,0 +1,138 @@
+package exchange
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"id3v2-tui/internal/metadata"
+)
+
+type TextEntry struct {
+	Path string
+	Meta *metadata.Metadata
+}
+
+const textHeader = `# Edit the values below, then save and quit the editor.
+# Lines starting with # are ignored. Set a value to nothing to clear it;
+# quote values with "..." to keep leading or trailing spaces.
+`
+
+func quoteValue(value string) string {
+	if value == "" {
+		return ""
+	}
+	needsQuotes := strings.TrimSpace(value) != value || strings.HasPrefix(value, `"`)
+	for _, r := range value {
+		if r < 0x20 || r == 0x7f {
+			needsQuotes = true
+		}
+	}
+	if needsQuotes {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+func WriteText(w io.Writer, entries []TextEntry) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprint(bw, textHeader)
+	for _, e := range entries {
+		fmt.Fprintf(bw, "\n[%s]\n", e.Path)
+		for _, name := range metadata.Fields() {
+			value, _ := e.Meta.Get(name)
+			fmt.Fprintf(bw, "%s = %s\n", name, quoteValue(value))
+		}
+	}
+	return bw.Flush()
+}
+
+func ReadText(r io.Reader) (map[string]map[string]string, error) {
+	entries := make(map[string]map[string]string)
+	var current map[string]string
+	var currentPath string
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+			currentPath = strings.TrimSpace(text[1 : len(text)-1])
+			if currentPath == "" {
+				return nil, fmt.Errorf("line %d: empty file path", line)
+			}
+			if _, ok := entries[currentPath]; ok {
+				return nil, fmt.Errorf("line %d: duplicate section [%s]", line, currentPath)
+			}
+			current = make(map[string]string)
+			entries[currentPath] = current
+			continue
+		}
+
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"field = value\"", line)
+		}
+		if current == nil {
+			return nil, fmt.Errorf("line %d: value outside of a [file] section", line)
+		}
+
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !metadata.IsField(key) {
+			return nil, fmt.Errorf("line %d: unknown field %q", line, key)
+		}
+		if _, ok := current[key]; ok {
+			return nil, fmt.Errorf("line %d: duplicate field %q in [%s]", line, key, currentPath)
+		}
+
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid quoted value %s", line, value)
+			}
+			value = unquoted
+		}
+		current[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func PlanText(original []TextEntry, edited map[string]map[string]string) ([]*Change, error) {
+	known := make(map[string]bool, len(original))
+	for _, e := range original {
+		known[e.Path] = true
+	}
+	for path := range edited {
+		if !known[path] {
+			return nil, fmt.Errorf("unexpected section [%s]", path)
+		}
+	}
+
+	var changes []*Change
+	for _, e := range original {
+		values, ok := edited[e.Path]
+		if !ok {
+			continue
+		}
+		after := e.Meta.Clone()
+		for name, value := range values {
+			after.Set(name, value)
+		}
+		if e.Meta.Diff(after) == "" {
+			continue
+		}
+		changes = append(changes, &Change{Path: e.Path, Before: e.Meta, After: after})
+	}
+	return changes, nil
+}
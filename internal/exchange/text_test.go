@@ -0,0 +1,92 @@
+package exchange
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"id3v2-tui/internal/metadata"
+)
+
+func TestTextRoundTrip(t *testing.T) {
+	entries := []TextEntry{
+		{Path: "/music/a.mp3", Meta: &metadata.Metadata{TrackName: "Song", Artist: " padded "}},
+		{Path: "/music/b.mp3", Meta: &metadata.Metadata{TrackName: "Other"}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteText(&buf, entries); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "[/music/a.mp3]\ntitle = Song\nartist = \" padded \"\n") {
+		t.Errorf("unexpected text output:\n%s", buf.String())
+	}
+
+	parsed, err := ReadText(&buf)
+	if err != nil {
+		t.Fatalf("ReadText failed: %v", err)
+	}
+	if parsed["/music/a.mp3"]["artist"] != " padded " {
+		t.Errorf("expected quoted value to round-trip, got %q", parsed["/music/a.mp3"]["artist"])
+	}
+
+	changes, err := PlanText(entries, parsed)
+	if err != nil {
+		t.Fatalf("PlanText failed: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("expected no changes for unedited text, got %d", len(changes))
+	}
+}
+
+func TestPlanTextChanges(t *testing.T) {
+	entries := []TextEntry{
+		{Path: "/music/a.mp3", Meta: &metadata.Metadata{TrackName: "Song", Album: "Album"}},
+	}
+	edited := "[/music/a.mp3]\ntitle = New Song\nalbum =\n"
+
+	parsed, err := ReadText(strings.NewReader(edited))
+	if err != nil {
+		t.Fatalf("ReadText failed: %v", err)
+	}
+	changes, err := PlanText(entries, parsed)
+	if err != nil {
+		t.Fatalf("PlanText failed: %v", err)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %d", len(changes))
+	}
+	if diff := changes[0].Diff(); diff != "Track: Song → New Song\nAlbum: Album → (removed)" {
+		t.Errorf("unexpected diff %q", diff)
+	}
+}
+
+func TestReadTextErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		contains string
+	}{
+		{"title = x\n", "outside"},
+		{"[a.mp3]\nbogus = x\n", "unknown field"},
+		{"[a.mp3]\ntitle\n", "expected"},
+		{"[a.mp3]\ntitle = x\ntitle = y\n", "duplicate field"},
+		{"[a.mp3]\n[a.mp3]\n", "duplicate section"},
+		{"[a.mp3]\ntitle = \"unterminated\n", "quoted"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contains, func(t *testing.T) {
+			_, err := ReadText(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("expected error containing %q, got %v", tt.contains, err)
+			}
+		})
+	}
+}
+
+func TestPlanTextUnknownSection(t *testing.T) {
+	entries := []TextEntry{{Path: "a.mp3", Meta: &metadata.Metadata{}}}
+	if _, err := PlanText(entries, map[string]map[string]string{"b.mp3": {}}); err == nil {
+		t.Error("expected error for unknown section")
+	}
+}
@@ -1,36 +1,114 @@
 package files
 
 import (
+	"context"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/rivo/tview"
 )
 
+type Item struct {
+	Name    string
+	Dir     bool
+	Size    int64
+	ModTime time.Time
+}
+
+func (i Item) Text() string {
+	if i.Dir {
+		return i.Name + "/"
+	}
+	return i.Name
+}
+
 func Load(list *tview.List, dir string) string {
-	list.Clear()
+	Reset(list, dir)
+	Stream(context.Background(), dir, true, func(items []Item) {
+		for _, item := range items {
+			Insert(list, item)
+		}
+	})
+	return dir
+}
 
-	parentDir := filepath.Dir(dir)
-	if parentDir != dir {
+func Reset(list *tview.List, dir string) {
+	list.Clear()
+	if filepath.Dir(dir) != dir {
 		list.AddItem("..", "Go to parent directory", 0, nil)
 	}
+	list.SetTitle("Files - " + filepath.Base(dir))
+}
 
-	files, err := os.ReadDir(dir)
+func Stream(ctx context.Context, dir string, hidden bool, fn func([]Item)) error {
+	f, err := os.Open(dir)
 	if err != nil {
-		return dir
+		return err
 	}
+	defer f.Close()
 
-	for _, file := range files {
-		if file.IsDir() {
-			list.AddItem(file.Name()+"/", " Directory", 0, nil)
-		} else if strings.HasSuffix(strings.ToLower(file.Name()), ".mp3") {
-			list.AddItem(file.Name(), " MP3 file", 0, nil)
+	for ctx.Err() == nil {
+		entries, err := f.ReadDir(256)
+		var items []Item
+		for _, entry := range entries {
+			if !hidden && strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			item := Item{Name: entry.Name(), Dir: entry.IsDir()}
+			if !item.Dir && !IsAudioFile(item.Name) {
+				continue
+			}
+			if info, err := entry.Info(); err == nil && !item.Dir {
+				item.Size = info.Size()
+				item.ModTime = info.ModTime()
+			}
+			items = append(items, item)
+		}
+		if len(items) > 0 {
+			fn(items)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
 		}
 	}
+	return ctx.Err()
+}
 
-	list.SetTitle("Files - " + filepath.Base(dir))
-	return dir
+func Insert(list *tview.List, item Item) int {
+	index := sort.Search(list.GetItemCount(), func(i int) bool {
+		mainText, _ := list.GetItemText(i)
+		return mainText != ".." && strings.TrimSuffix(mainText, "/") > item.Name
+	})
+	list.InsertItem(index, item.Text(), item.description(), 0, nil)
+	return index
+}
+
+func Add(list *tview.List, item Item) int {
+	list.AddItem(item.Text(), item.description(), 0, nil)
+	return list.GetItemCount() - 1
+}
+
+func (i Item) description() string {
+	if i.Dir {
+		return " Directory"
+	}
+	return " " + FileType(i.Name)
+}
+
+func FileType(name string) string {
+	ext := strings.ToUpper(strings.TrimPrefix(filepath.Ext(name), "."))
+	if ext == "" {
+		return "Audio file"
+	}
+	return ext + " file"
 }
 
 func GetSelectedPath(list *tview.List, currentDir string) string {
@@ -58,3 +136,113 @@ func ResolveDirectory(currentDir, entry string) string {
 	}
 	return filepath.Join(currentDir, entry)
 }
+
+var Extensions = []string{".mp3"}
+
+func IsAudioFile(name string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range Extensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func ListAudioFiles(dir string, recursive bool) ([]string, error) {
+	var paths []string
+	if !recursive {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && IsAudioFile(entry.Name()) {
+				paths = append(paths, filepath.Join(dir, entry.Name()))
+			}
+		}
+		return paths, nil
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && IsAudioFile(d.Name()) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+func SetMarked(list *tview.List, index int, marked bool) {
+	SetInfo(list, index, marked, "")
+}
+
+func SetInfo(list *tview.List, index int, marked bool, info string) {
+	mainText, _ := list.GetItemText(index)
+	if IsDirectoryEntry(mainText) {
+		return
+	}
+	switch {
+	case info == "" && marked:
+		info = "✓ Selected"
+	case info == "":
+		info = FileType(mainText)
+	case marked:
+		info = "✓ " + info
+	}
+	list.SetItemText(index, mainText, " "+info)
+}
+
+func NaturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		ca, cb := a[0], b[0]
+		if isDigit(ca) && isDigit(cb) {
+			na, restA := leadingDigits(a)
+			nb, restB := leadingDigits(b)
+			ta, tb := strings.TrimLeft(na, "0"), strings.TrimLeft(nb, "0")
+			if len(ta) != len(tb) {
+				return len(ta) < len(tb)
+			}
+			if ta != tb {
+				return ta < tb
+			}
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			a, b = restA, restB
+			continue
+		}
+
+		la, lb := lower(ca), lower(cb)
+		if la != lb {
+			return la < lb
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func lower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+func leadingDigits(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
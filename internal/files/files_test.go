@@ -1,6 +1,8 @@
 package files
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -181,3 +183,149 @@ func TestLoadParentDirectory(t *testing.T) {
 		t.Error("expected '..' entry for non-root directory")
 	}
 }
+
+func TestListAudioFiles(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.mp3", "b.MP3", "c.txt", filepath.Join("sub", "d.mp3")} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	flat, err := ListAudioFiles(dir, false)
+	if err != nil {
+		t.Fatalf("ListAudioFiles failed: %v", err)
+	}
+	if len(flat) != 2 {
+		t.Errorf("expected 2 files, got %v", flat)
+	}
+
+	recursive, err := ListAudioFiles(dir, true)
+	if err != nil {
+		t.Fatalf("ListAudioFiles failed: %v", err)
+	}
+	if len(recursive) != 3 {
+		t.Errorf("expected 3 files, got %v", recursive)
+	}
+}
+
+func TestSetMarked(t *testing.T) {
+	list := tview.NewList()
+	list.AddItem("..", "Go to parent directory", 0, nil)
+	list.AddItem("song.mp3", " MP3 file", 0, nil)
+
+	SetMarked(list, 1, true)
+	mainText, secondaryText := list.GetItemText(1)
+	if mainText != "song.mp3" || !strings.Contains(secondaryText, "Selected") {
+		t.Errorf("unexpected item text %q / %q", mainText, secondaryText)
+	}
+
+	SetMarked(list, 1, false)
+	if _, secondaryText := list.GetItemText(1); secondaryText != " MP3 file" {
+		t.Errorf("expected mark to be removed, got %q", secondaryText)
+	}
+
+	list.AddItem("song.flac", "", 0, nil)
+	SetMarked(list, 2, false)
+	if _, secondaryText := list.GetItemText(2); secondaryText != " FLAC file" {
+		t.Errorf("expected the label to follow the extension, got %q", secondaryText)
+	}
+
+	SetMarked(list, 0, true)
+	if _, secondaryText := list.GetItemText(0); secondaryText != "Go to parent directory" {
+		t.Errorf("expected directory entry to be untouched, got %q", secondaryText)
+	}
+}
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"track2.mp3", "track10.mp3", true},
+		{"track10.mp3", "track2.mp3", false},
+		{"01 intro.mp3", "2 song.mp3", true},
+		{"Alpha.mp3", "beta.mp3", true},
+		{"cd1/10.mp3", "cd2/1.mp3", true},
+		{"a", "a", false},
+		{"a", "ab", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"<"+tt.b, func(t *testing.T) {
+			if got := NaturalLess(tt.a, tt.b); got != tt.expected {
+				t.Errorf("NaturalLess(%q, %q) = %v, expected %v", tt.a, tt.b, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsAudioFileExtensions(t *testing.T) {
+	defer func(saved []string) { Extensions = saved }(Extensions)
+
+	if !IsAudioFile("song.MP3") || IsAudioFile("song.flac") {
+		t.Error("expected only .mp3 by default")
+	}
+
+	Extensions = []string{".mp3", ".mp2"}
+	if !IsAudioFile("song.mp2") || IsAudioFile("song.mp") {
+		t.Error("expected configured extensions to be used")
+	}
+}
+
+func TestStream(t *testing.T) {
+	dir := t.TempDir()
+	for i := range 300 {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%03d.mp3", i)), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var items []Item
+	batches := 0
+	if err := Stream(context.Background(), dir, false, func(batch []Item) {
+		batches++
+		items = append(items, batch...)
+	}); err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	if len(items) != 301 || batches < 2 {
+		t.Errorf("expected 301 items in several batches, got %d in %d", len(items), batches)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Stream(ctx, dir, false, func([]Item) { t.Error("unexpected batch after cancel") }); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if err := Stream(context.Background(), filepath.Join(dir, "missing"), false, nil); !os.IsNotExist(err) {
+		t.Errorf("expected not-exist error, got %v", err)
+	}
+}
+
+func TestInsert(t *testing.T) {
+	list := tview.NewList()
+	Reset(list, "/music/album")
+	for _, item := range []Item{{Name: "b.mp3"}, {Name: "a", Dir: true}, {Name: "c.mp3"}, {Name: "a.mp3"}} {
+		Insert(list, item)
+	}
+
+	var got []string
+	for i := range list.GetItemCount() {
+		mainText, _ := list.GetItemText(i)
+		got = append(got, mainText)
+	}
+	if want := []string{"..", "a/", "a.mp3", "b.mp3", "c.mp3"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
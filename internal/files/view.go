@@ -0,0 +1,185 @@
+package files
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+var SortKeys = []string{"name", "mtime", "size"}
+
+var ColumnNames = []string{"size", "mtime"}
+
+type View struct {
+	Sort       string   `json:"sort"`
+	Descending bool     `json:"descending,omitempty"`
+	Hidden     bool     `json:"hidden,omitempty"`
+	Columns    []string `json:"columns,omitempty"`
+}
+
+func DefaultView() View {
+	return View{Sort: "name"}
+}
+
+func (v View) Shows(column string) bool {
+	for _, c := range v.Columns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+func (v View) Info(item Item) []string {
+	var info []string
+	if item.Dir {
+		return info
+	}
+	if v.Shows("size") {
+		info = append(info, FormatSize(item.Size))
+	}
+	if v.Shows("mtime") && !item.ModTime.IsZero() {
+		info = append(info, item.ModTime.Format("2006-01-02 15:04"))
+	}
+	return info
+}
+
+func (v View) SortItems(items []Item, compare func(a, b Item) int) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.Dir != b.Dir {
+			return a.Dir
+		}
+		c := 0
+		if !a.Dir {
+			switch v.Sort {
+			case "name", "":
+			case "mtime":
+				c = a.ModTime.Compare(b.ModTime)
+			case "size":
+				c = cmpInt(a.Size, b.Size)
+			default:
+				if compare != nil {
+					c = compare(a, b)
+				}
+			}
+		}
+		if c == 0 {
+			if a.Dir || !v.Descending {
+				return NaturalLess(a.Name, b.Name)
+			}
+			return NaturalLess(b.Name, a.Name)
+		}
+		return c < 0 != v.Descending
+	})
+}
+
+func cmpInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func FormatSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+type Views struct {
+	Default  View            `json:"default"`
+	Dirs     map[string]View `json:"dirs,omitempty"`
+	path     string
+	fallback View
+	custom   bool
+}
+
+func LoadViews(path string, fallback View) (*Views, error) {
+	views := &Views{Default: fallback, Dirs: make(map[string]View), path: path, fallback: fallback}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) || path == "" {
+		return views, nil
+	}
+	if err != nil {
+		return views, err
+	}
+	var saved Views
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return views, fmt.Errorf("%s: %w", path, err)
+	}
+	if saved.Default.Sort != "" {
+		views.Default = saved.Default
+		views.custom = true
+	}
+	for dir, v := range saved.Dirs {
+		views.Dirs[dir] = v
+	}
+	return views, nil
+}
+
+func (vs *Views) For(dir string) (View, bool) {
+	if v, ok := vs.Dirs[dir]; ok {
+		return v, true
+	}
+	return vs.Default, false
+}
+
+func (vs *Views) Set(dir string, v View) error {
+	if dir == "" {
+		vs.Default = v
+		vs.custom = true
+	} else {
+		vs.Dirs[dir] = v
+	}
+	return vs.save()
+}
+
+func (vs *Views) Forget(dir string) error {
+	delete(vs.Dirs, dir)
+	return vs.save()
+}
+
+func (vs *Views) Reset(dir string) error {
+	delete(vs.Dirs, dir)
+	vs.Default = vs.fallback
+	vs.custom = false
+	return vs.save()
+}
+
+func (vs *Views) save() error {
+	if vs.path == "" {
+		return nil
+	}
+	saved := struct {
+		Default *View           `json:"default,omitempty"`
+		Dirs    map[string]View `json:"dirs,omitempty"`
+	}{Dirs: vs.Dirs}
+	if vs.custom {
+		saved.Default = &vs.Default
+	}
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(vs.path), 0o755); err != nil {
+		return err
+	}
+	tmp := vs.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, vs.path)
+}
@@ -0,0 +1,121 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func names(items []Item) string {
+	out := make([]string, len(items))
+	for i, item := range items {
+		out[i] = item.Text()
+	}
+	return strings.Join(out, ",")
+}
+
+func TestSortItems(t *testing.T) {
+	now := time.Now()
+	items := []Item{
+		{Name: "track10.mp3", Size: 300, ModTime: now},
+		{Name: "b", Dir: true},
+		{Name: "track2.mp3", Size: 100, ModTime: now.Add(-time.Hour)},
+		{Name: "a", Dir: true},
+		{Name: "track1.mp3", Size: 200, ModTime: now.Add(time.Hour)},
+	}
+
+	tests := []struct {
+		view View
+		want string
+	}{
+		{View{Sort: "name"}, "a/,b/,track1.mp3,track2.mp3,track10.mp3"},
+		{View{Sort: "name", Descending: true}, "a/,b/,track10.mp3,track2.mp3,track1.mp3"},
+		{View{Sort: "size"}, "a/,b/,track2.mp3,track1.mp3,track10.mp3"},
+		{View{Sort: "mtime", Descending: true}, "a/,b/,track1.mp3,track10.mp3,track2.mp3"},
+		{View{Sort: "title"}, "a/,b/,track10.mp3,track1.mp3,track2.mp3"},
+	}
+	byLength := func(a, b Item) int { return len(b.Name) - len(a.Name) }
+	for _, tt := range tests {
+		sorted := append([]Item(nil), items...)
+		tt.view.SortItems(sorted, byLength)
+		if got := names(sorted); got != tt.want {
+			t.Errorf("%+v: expected %s, got %s", tt.view, tt.want, got)
+		}
+	}
+}
+
+func TestViewInfo(t *testing.T) {
+	modTime := time.Date(2024, 5, 1, 14, 3, 0, 0, time.Local)
+	item := Item{Name: "a.mp3", Size: 3 << 20, ModTime: modTime}
+	v := View{Columns: []string{"size", "mtime"}}
+	if got := strings.Join(v.Info(item), " "); got != "3.0 MB 2024-05-01 14:03" {
+		t.Errorf("unexpected info %q", got)
+	}
+	if info := v.Info(Item{Name: "dir", Dir: true}); len(info) != 0 {
+		t.Errorf("expected no info for directories, got %v", info)
+	}
+}
+
+func TestViews(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "views.json")
+	fallback := View{Sort: "size"}
+
+	views, err := LoadViews(path, fallback)
+	if err != nil {
+		t.Fatalf("LoadViews failed: %v", err)
+	}
+	if v, own := views.For("/music"); own || v.Sort != "size" {
+		t.Errorf("expected the fallback view, got %+v (own %v)", v, own)
+	}
+
+	if err := views.Set("/music", View{Sort: "mtime", Hidden: true}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := views.Set("", View{Sort: "artist", Columns: []string{"size"}}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	reloaded, err := LoadViews(path, fallback)
+	if err != nil {
+		t.Fatalf("LoadViews failed: %v", err)
+	}
+	if v, own := reloaded.For("/music"); !own || v.Sort != "mtime" || !v.Hidden {
+		t.Errorf("expected the saved directory view, got %+v (own %v)", v, own)
+	}
+	if v, _ := reloaded.For("/other"); v.Sort != "artist" || !v.Shows("size") {
+		t.Errorf("expected the saved default view, got %+v", v)
+	}
+
+	if err := reloaded.Forget("/music"); err != nil {
+		t.Fatalf("Forget failed: %v", err)
+	}
+	if _, own := reloaded.For("/music"); own {
+		t.Error("expected the directory view to be forgotten")
+	}
+
+	if err := reloaded.Set("/music", View{Sort: "mtime"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := reloaded.Reset("/music"); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if v, own := reloaded.For("/music"); own || v.Sort != "size" {
+		t.Errorf("expected the configured view after a reset, got %+v (own %v)", v, own)
+	}
+	reset, err := LoadViews(path, View{Sort: "name"})
+	if err != nil {
+		t.Fatalf("LoadViews failed: %v", err)
+	}
+	if v, _ := reset.For("/other"); v.Sort != "name" {
+		t.Errorf("expected a changed configuration to apply after a reset, got %+v", v)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if views, err := LoadViews(path, fallback); err == nil || views.Default.Sort != "size" {
+		t.Errorf("expected an error and the fallback view, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package hooks
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"id3v2-tui/internal/commands"
+	"id3v2-tui/internal/metadata"
+)
+
+type Event string
+
+const (
+	EventSave   Event = "save"
+	EventRename Event = "rename"
+	EventBatch  Event = "batch"
+)
+
+func Events() []Event {
+	return []Event{EventSave, EventRename, EventBatch}
+}
+
+type Hooks map[Event][]string
+
+func FromEnv() Hooks {
+	hooks := make(Hooks)
+	for _, event := range Events() {
+		if command := strings.TrimSpace(os.Getenv("ID3V2_TUI_HOOK_" + strings.ToUpper(string(event)))); command != "" {
+			hooks[event] = append(hooks[event], command)
+		}
+	}
+	return hooks
+}
+
+type FieldChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+type FileChange struct {
+	Path    string                 `json:"path"`
+	From    string                 `json:"from,omitempty"`
+	Changes map[string]FieldChange `json:"changes,omitempty"`
+}
+
+type Payload struct {
+	Event Event        `json:"event"`
+	Files []FileChange `json:"files"`
+}
+
+func Changes(before, after *metadata.Metadata) map[string]FieldChange {
+	changes := make(map[string]FieldChange)
+	for _, name := range metadata.Fields() {
+		old, _ := before.Get(name)
+		value, _ := after.Get(name)
+		if old != value {
+			changes[name] = FieldChange{Old: old, New: value}
+		}
+	}
+	if after.CoverPath != "" && after.CoverPath != before.CoverPath {
+		changes["cover"] = FieldChange{Old: before.CoverPath, New: after.CoverPath}
+	}
+	return changes
+}
+
+const maxEnvValue = 32 << 10
+
+type Result struct {
+	Command string
+	Output  string
+	Err     error
+}
+
+func Run(executor commands.Executor, command string, payload Payload) Result {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Result{Command: command, Err: err}
+	}
+
+	paths := make([]string, len(payload.Files))
+	for i, f := range payload.Files {
+		paths[i] = f.Path
+	}
+	env := []string{"ID3V2_TUI_EVENT=" + string(payload.Event)}
+	if len(paths) > 0 {
+		env = append(env, "ID3V2_TUI_FILE="+paths[0])
+	}
+	if files := strings.Join(paths, "\n"); len(files) <= maxEnvValue {
+		env = append(env, "ID3V2_TUI_FILES="+files)
+	}
+	if len(data) <= maxEnvValue {
+		env = append(env, "ID3V2_TUI_DIFF="+string(data))
+	}
+
+	output, err := executor.RunWithInput(string(data), env, "sh", "-c", command)
+	return Result{Command: command, Output: output, Err: err}
+}
+
+func (h Hooks) Run(executor commands.Executor, payload Payload, fn func(Result)) {
+	for _, command := range h[payload.Event] {
+		fn(Run(executor, command, payload))
+	}
+}
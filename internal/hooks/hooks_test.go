@@ -0,0 +1,123 @@
+package hooks
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"id3v2-tui/internal/metadata"
+)
+
+type fakeExecutor struct {
+	input string
+	env   []string
+	args  []string
+	err   error
+}
+
+func (f *fakeExecutor) Run(name string, args ...string) (string, error) {
+	return "", nil
+}
+
+func (f *fakeExecutor) RunInteractive(name string, args ...string) error {
+	return nil
+}
+
+func (f *fakeExecutor) RunWithInput(input string, env []string, name string, args ...string) (string, error) {
+	f.input = input
+	f.env = env
+	f.args = append([]string{name}, args...)
+	return "done\n", f.err
+}
+
+func TestChanges(t *testing.T) {
+	before := &metadata.Metadata{TrackName: "Old", Artist: "Same"}
+	after := &metadata.Metadata{TrackName: "New", Artist: "Same", CoverPath: "/tmp/cover.jpg"}
+
+	changes := Changes(before, after)
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %v", changes)
+	}
+	if changes["title"] != (FieldChange{Old: "Old", New: "New"}) {
+		t.Errorf("unexpected title change %v", changes["title"])
+	}
+	if changes["cover"].New != "/tmp/cover.jpg" {
+		t.Errorf("unexpected cover change %v", changes["cover"])
+	}
+}
+
+func TestRun(t *testing.T) {
+	executor := &fakeExecutor{}
+	payload := Payload{
+		Event: EventSave,
+		Files: []FileChange{{Path: "/music/a.mp3", Changes: map[string]FieldChange{"title": {Old: "A", New: "B"}}}},
+	}
+
+	result := Run(executor, "mpc update", payload)
+	if result.Err != nil || result.Output != "done\n" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if strings.Join(executor.args, " ") != "sh -c mpc update" {
+		t.Errorf("unexpected command %v", executor.args)
+	}
+
+	var decoded Payload
+	if err := json.Unmarshal([]byte(executor.input), &decoded); err != nil {
+		t.Fatalf("stdin is not JSON: %v", err)
+	}
+	if decoded.Event != EventSave || decoded.Files[0].Changes["title"].New != "B" {
+		t.Errorf("unexpected payload %+v", decoded)
+	}
+
+	env := strings.Join(executor.env, "\n")
+	for _, want := range []string{"ID3V2_TUI_EVENT=save", "ID3V2_TUI_FILE=/music/a.mp3", "ID3V2_TUI_DIFF={"} {
+		if !strings.Contains(env, want) {
+			t.Errorf("expected %q in env %v", want, executor.env)
+		}
+	}
+
+	large := Payload{Event: EventBatch}
+	for range 2000 {
+		large.Files = append(large.Files, FileChange{Path: "/music/some/rather/long/path/to/a/track.mp3", Changes: map[string]FieldChange{"album": {Old: "Old album", New: "New album"}}})
+	}
+	Run(executor, "sync", large)
+	env = strings.Join(executor.env, "\n")
+	if strings.Contains(env, "ID3V2_TUI_DIFF=") || strings.Contains(env, "ID3V2_TUI_FILES=") || !strings.Contains(env, "ID3V2_TUI_FILE=") {
+		t.Errorf("large payloads should only be passed on stdin, got %d bytes of env", len(env))
+	}
+	if json.Unmarshal([]byte(executor.input), &decoded) != nil || len(decoded.Files) != 2000 {
+		t.Error("expected the full payload on stdin")
+	}
+}
+
+func TestHooksRunMatchesEvent(t *testing.T) {
+	executor := &fakeExecutor{err: errors.New("exit status 1")}
+	hooks := Hooks{EventRename: {"first", "second"}, EventSave: {"other"}}
+
+	var results []Result
+	collect := func(r Result) { results = append(results, r) }
+	hooks.Run(executor, Payload{Event: EventRename}, collect)
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].Command != "first" || results[1].Err == nil {
+		t.Errorf("unexpected results %+v", results)
+	}
+	results = nil
+	hooks.Run(executor, Payload{Event: EventBatch}, collect)
+	if len(results) != 0 {
+		t.Error("expected no hooks for batch")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("ID3V2_TUI_HOOK_SAVE", "mpc update")
+	t.Setenv("ID3V2_TUI_HOOK_RENAME", "")
+	t.Setenv("ID3V2_TUI_HOOK_BATCH", "rsync -a ~/Music device:")
+
+	hooks := FromEnv()
+	if len(hooks[EventSave]) != 1 || len(hooks[EventRename]) != 0 || hooks[EventBatch][0] != "rsync -a ~/Music device:" {
+		t.Errorf("unexpected hooks %v", hooks)
+	}
+}
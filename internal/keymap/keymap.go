@@ -0,0 +1,361 @@
+package keymap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+type Context string
+
+const (
+	ContextGlobal Context = "global"
+	ContextList   Context = "list"
+	ContextForm   Context = "form"
+	ContextModal  Context = "modal"
+)
+
+func Contexts() []Context {
+	return []Context{ContextGlobal, ContextList, ContextForm, ContextModal}
+}
+
+var contextTitles = map[Context]string{
+	ContextGlobal: "Everywhere",
+	ContextList:   "File list",
+	ContextForm:   "Form",
+	ContextModal:  "Dialogs",
+}
+
+type Action struct {
+	Name        string
+	Description string
+	Modal       bool
+}
+
+var actions = []Action{
+	{"quit", "Quit", false},
+	{"help", "Show this help", false},
+	{"command_palette", "Search and run an action", false},
+	{"save", "Save the form", false},
+	{"clear_form", "Clear the form", false},
+	{"focus_next", "Focus the next field", true},
+	{"focus_prev", "Focus the previous field", true},
+	{"toggle_selection", "Select or deselect a file", false},
+	{"edit_selection", "Edit the selected files together", false},
+	{"toggle_library", "Switch between the file list and the library table", false},
+	{"filter", "Filter the file list or library", false},
+	{"sort", "Choose the sort order and view options", false},
+	{"toggle_hidden", "Show or hide hidden files", false},
+	{"find_replace", "Find and replace in tags", false},
+	{"text_actions", "Text actions", false},
+	{"fix_encoding", "Fix mis-decoded text", false},
+	{"number_tracks", "Number tracks", false},
+	{"export_csv", "Export selection to CSV/TSV", false},
+	{"import_csv", "Import CSV/TSV", false},
+	{"edit_in_editor", "Edit tags in $EDITOR", false},
+	{"export_cover", "Export the cover picture", false},
+	{"strip", "Strip tags or apply a clean-up profile", false},
+	{"convert_version", "Convert the ID3v2 version", false},
+	{"toggle_log", "Show or hide the log", false},
+	{"next_theme", "Switch to the next theme", false},
+	{"rename", "Rename files from tags", false},
+	{"undo_rename", "Undo last rename", false},
+	{"cancel", "Close the dialog", true},
+	{"toggle", "Toggle the current item", true},
+}
+
+func Actions() []Action {
+	return append([]Action(nil), actions...)
+}
+
+func LookupAction(name string) (Action, bool) {
+	for _, a := range actions {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Action{}, false
+}
+
+func allowed(action Action, ctx Context) bool {
+	if ctx == ContextModal {
+		return action.Modal
+	}
+	return action.Name != "cancel" && action.Name != "toggle"
+}
+
+var namedKeys = map[tcell.Key]string{
+	tcell.KeyTab:        "tab",
+	tcell.KeyBacktab:    "backtab",
+	tcell.KeyEnter:      "enter",
+	tcell.KeyEscape:     "esc",
+	tcell.KeyBackspace:  "backspace",
+	tcell.KeyBackspace2: "backspace",
+	tcell.KeyDelete:     "delete",
+	tcell.KeyInsert:     "insert",
+	tcell.KeyUp:         "up",
+	tcell.KeyDown:       "down",
+	tcell.KeyLeft:       "left",
+	tcell.KeyRight:      "right",
+	tcell.KeyHome:       "home",
+	tcell.KeyEnd:        "end",
+	tcell.KeyPgUp:       "pgup",
+	tcell.KeyPgDn:       "pgdn",
+}
+
+var aliases = map[string]string{
+	"shift+tab": "backtab",
+	"escape":    "esc",
+	"return":    "enter",
+	"pageup":    "pgup",
+	"pagedown":  "pgdn",
+	"del":       "delete",
+	" ":         "space",
+}
+
+func KeyName(event *tcell.EventKey) string {
+	key := event.Key()
+	if key == tcell.KeyRune {
+		r := event.Rune()
+		name := string(r)
+		if r == ' ' {
+			name = "space"
+		}
+		if event.Modifiers()&tcell.ModCtrl != 0 && r < utf8.RuneSelf {
+			return "ctrl+" + strings.ToLower(name)
+		}
+		if event.Modifiers()&tcell.ModAlt != 0 {
+			return "alt+" + name
+		}
+		return name
+	}
+	if name, ok := namedKeys[key]; ok {
+		return name
+	}
+	if key >= tcell.KeyF1 && key <= tcell.KeyF12 {
+		return fmt.Sprintf("f%d", key-tcell.KeyF1+1)
+	}
+	if key >= tcell.KeyCtrlA && key <= tcell.KeyCtrlZ {
+		return "ctrl+" + string(rune('a'+key-tcell.KeyCtrlA))
+	}
+	return strings.ToLower(event.Name())
+}
+
+func ParseKey(s string) (string, error) {
+	if s == " " {
+		return "space", nil
+	}
+	name := strings.TrimSpace(s)
+	if utf8.RuneCountInString(name) == 1 {
+		return name, nil
+	}
+	if len(name) > 4 && strings.EqualFold(name[:4], "alt+") {
+		rest := name[4:]
+		if strings.EqualFold(rest, "space") {
+			return "alt+space", nil
+		}
+		if utf8.RuneCountInString(rest) == 1 {
+			return "alt+" + rest, nil
+		}
+		return "", fmt.Errorf("unknown key %q", s)
+	}
+	name = strings.ToLower(name)
+	if alias, ok := aliases[name]; ok {
+		return alias, nil
+	}
+	if name == "space" {
+		return name, nil
+	}
+	for _, known := range namedKeys {
+		if name == known {
+			return name, nil
+		}
+	}
+	for i := 1; i <= 12; i++ {
+		if name == fmt.Sprintf("f%d", i) {
+			return name, nil
+		}
+	}
+	if rest, ok := strings.CutPrefix(name, "ctrl+"); ok && len(rest) == 1 && rest[0] >= 'a' && rest[0] <= 'z' {
+		return name, nil
+	}
+	return "", fmt.Errorf("unknown key %q", s)
+}
+
+func isRune(name string) bool {
+	return name == "space" || utf8.RuneCountInString(name) == 1
+}
+
+type Keymap struct {
+	bindings map[Context]map[string]string
+}
+
+func New() *Keymap {
+	k := &Keymap{bindings: make(map[Context]map[string]string)}
+	for _, ctx := range Contexts() {
+		k.bindings[ctx] = make(map[string]string)
+	}
+	return k
+}
+
+func Default() *Keymap {
+	k := New()
+	defaults := []struct {
+		ctx    Context
+		action string
+		keys   []string
+	}{
+		{ContextGlobal, "quit", []string{"ctrl+q"}},
+		{ContextGlobal, "help", []string{"f1"}},
+		{ContextGlobal, "command_palette", []string{"ctrl+p"}},
+		{ContextGlobal, "save", []string{"ctrl+s"}},
+		{ContextGlobal, "clear_form", []string{"esc"}},
+		{ContextGlobal, "focus_next", []string{"tab"}},
+		{ContextGlobal, "focus_prev", []string{"backtab"}},
+		{ContextGlobal, "find_replace", []string{"ctrl+f"}},
+		{ContextGlobal, "text_actions", []string{"ctrl+t"}},
+		{ContextGlobal, "fix_encoding", []string{"alt+e"}},
+		{ContextGlobal, "number_tracks", []string{"ctrl+n"}},
+		{ContextGlobal, "export_csv", []string{"ctrl+x"}},
+		{ContextGlobal, "import_csv", []string{"alt+i"}},
+		{ContextGlobal, "edit_in_editor", []string{"ctrl+o"}},
+		{ContextGlobal, "toggle_log", []string{"ctrl+l"}},
+		{ContextGlobal, "next_theme", []string{"ctrl+g"}},
+		{ContextGlobal, "edit_selection", []string{"ctrl+b"}},
+		{ContextGlobal, "toggle_library", []string{"ctrl+d"}},
+		{ContextGlobal, "rename", []string{"ctrl+r"}},
+		{ContextGlobal, "undo_rename", []string{"ctrl+z"}},
+		{ContextList, "quit", []string{"q"}},
+		{ContextList, "help", []string{"?"}},
+		{ContextList, "toggle_selection", []string{"space"}},
+		{ContextList, "filter", []string{"/"}},
+		{ContextList, "sort", []string{"s"}},
+		{ContextList, "toggle_hidden", []string{"."}},
+		{ContextModal, "cancel", []string{"esc"}},
+		{ContextModal, "toggle", []string{"space"}},
+		{ContextModal, "focus_next", []string{"tab"}},
+		{ContextModal, "focus_prev", []string{"backtab"}},
+	}
+	for _, d := range defaults {
+		if err := k.Bind(d.ctx, d.action, d.keys); err != nil {
+			panic(err)
+		}
+	}
+	return k
+}
+
+func (k *Keymap) Bind(ctx Context, action string, keys []string) error {
+	bindings, ok := k.bindings[ctx]
+	if !ok {
+		return fmt.Errorf("unknown context %q", ctx)
+	}
+	a, ok := LookupAction(action)
+	if !ok {
+		return fmt.Errorf("unknown action %q", action)
+	}
+	if !allowed(a, ctx) {
+		return fmt.Errorf("action %q cannot be bound in the %s context", action, ctx)
+	}
+
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		name, err := ParseKey(key)
+		if err != nil {
+			return err
+		}
+		if other, ok := bindings[name]; ok && other != action {
+			return fmt.Errorf("%s is already bound to %q in the %s context", name, other, ctx)
+		}
+		names[i] = name
+	}
+
+	k.Unbind(ctx, action)
+	for _, name := range names {
+		bindings[name] = action
+	}
+	return nil
+}
+
+func (k *Keymap) Unbind(ctx Context, action string) {
+	for key, bound := range k.bindings[ctx] {
+		if bound == action {
+			delete(k.bindings[ctx], key)
+		}
+	}
+}
+
+func (k *Keymap) Lookup(ctx Context, event *tcell.EventKey, typing bool) (string, bool) {
+	name := KeyName(event)
+	if typing && isRune(name) {
+		return "", false
+	}
+	if action, ok := k.bindings[ctx][name]; ok {
+		return action, true
+	}
+	if ctx == ContextModal {
+		return "", false
+	}
+	action, ok := k.bindings[ContextGlobal][name]
+	return action, ok
+}
+
+func (k *Keymap) Keys(ctx Context, action string) []string {
+	var keys []string
+	for key, bound := range k.bindings[ctx] {
+		if bound == action {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func Display(key string) string {
+	if utf8.RuneCountInString(key) == 1 {
+		return key
+	}
+	if key == "backtab" {
+		return "Shift+Tab"
+	}
+	if rest, ok := strings.CutPrefix(key, "ctrl+"); ok {
+		return "Ctrl+" + strings.ToUpper(rest)
+	}
+	if rest, ok := strings.CutPrefix(key, "alt+"); ok {
+		return "Alt+" + rest
+	}
+	return strings.ToUpper(key[:1]) + key[1:]
+}
+
+func (k *Keymap) Hint(action string, contexts ...Context) string {
+	for _, ctx := range contexts {
+		if keys := k.Keys(ctx, action); len(keys) > 0 {
+			return Display(keys[0])
+		}
+	}
+	return ""
+}
+
+func (k *Keymap) Help(available func(action string) bool) string {
+	var b strings.Builder
+	for _, ctx := range Contexts() {
+		var lines []string
+		for _, a := range actions {
+			keys := k.Keys(ctx, a.Name)
+			if len(keys) == 0 || (available != nil && !available(a.Name)) {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("  %-18s %s", strings.Join(keys, ", "), a.Description))
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(contextTitles[ctx] + "\n" + strings.Join(lines, "\n") + "\n")
+	}
+	return b.String()
+}
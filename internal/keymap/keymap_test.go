@@ -0,0 +1,174 @@
+package keymap
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestKeyName(t *testing.T) {
+	tests := []struct {
+		event *tcell.EventKey
+		want  string
+	}{
+		{tcell.NewEventKey(tcell.KeyRune, 'q', tcell.ModNone), "q"},
+		{tcell.NewEventKey(tcell.KeyRune, ' ', tcell.ModNone), "space"},
+		{tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModAlt), "alt+x"},
+		{tcell.NewEventKey(tcell.KeyCtrlS, 0, tcell.ModCtrl), "ctrl+s"},
+		{tcell.NewEventKey(tcell.KeyF1, 0, tcell.ModNone), "f1"},
+		{tcell.NewEventKey(tcell.KeyBacktab, 0, tcell.ModNone), "backtab"},
+		{tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone), "esc"},
+	}
+
+	for _, tt := range tests {
+		if got := KeyName(tt.event); got != tt.want {
+			t.Errorf("KeyName(%s) = %q, want %q", tt.event.Name(), got, tt.want)
+		}
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	tests := map[string]string{
+		"Ctrl+S":    "ctrl+s",
+		"shift+tab": "backtab",
+		"Escape":    "esc",
+		" ":         "space",
+		"F12":       "f12",
+		"Q":         "Q",
+		"alt+x":     "alt+x",
+		"Alt+X":     "alt+X",
+		"ALT+space": "alt+space",
+	}
+	for input, want := range tests {
+		got, err := ParseKey(input)
+		if err != nil || got != want {
+			t.Errorf("ParseKey(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+
+	for _, input := range []string{"ctrl+1", "f13", "hyper+x", ""} {
+		if _, err := ParseKey(input); err == nil {
+			t.Errorf("ParseKey(%q) should fail", input)
+		}
+	}
+}
+
+func TestParseKeyMatchesKeyName(t *testing.T) {
+	for _, r := range []rune{'x', 'X', ' '} {
+		event := tcell.NewEventKey(tcell.KeyRune, r, tcell.ModAlt)
+		name := KeyName(event)
+		input := "Alt+" + string(r)
+		if r == ' ' {
+			input = "Alt+Space"
+		}
+		if got, err := ParseKey(input); err != nil || got != name {
+			t.Errorf("ParseKey(%q) = %q, %v; KeyName gives %q", input, got, err, name)
+		}
+	}
+}
+
+func TestDefaultsLeaveInputEditingKeys(t *testing.T) {
+	k := Default()
+	for _, key := range []tcell.Key{tcell.KeyCtrlA, tcell.KeyCtrlE, tcell.KeyCtrlK, tcell.KeyCtrlU, tcell.KeyCtrlW} {
+		event := tcell.NewEventKey(key, 0, tcell.ModCtrl)
+		if action, ok := k.Lookup(ContextForm, event, true); ok {
+			t.Errorf("%s is bound to %q and would shadow text editing", KeyName(event), action)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	k := Default()
+	q := tcell.NewEventKey(tcell.KeyRune, 'q', tcell.ModNone)
+
+	if action, ok := k.Lookup(ContextList, q, false); !ok || action != "quit" {
+		t.Errorf("q in the file list = %q, %v", action, ok)
+	}
+	if _, ok := k.Lookup(ContextForm, q, true); ok {
+		t.Error("q must not trigger an action while typing")
+	}
+	if _, ok := k.Lookup(ContextList, q, true); ok {
+		t.Error("rune keys must never match while typing")
+	}
+
+	ctrlS := tcell.NewEventKey(tcell.KeyCtrlS, 0, tcell.ModCtrl)
+	if action, ok := k.Lookup(ContextForm, ctrlS, true); !ok || action != "save" {
+		t.Errorf("ctrl+s should fall back to the global binding, got %q, %v", action, ok)
+	}
+	if _, ok := k.Lookup(ContextModal, ctrlS, false); ok {
+		t.Error("dialogs must not fall back to global bindings")
+	}
+
+	esc := tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone)
+	if action, _ := k.Lookup(ContextModal, esc, true); action != "cancel" {
+		t.Errorf("esc in a dialog = %q, want cancel", action)
+	}
+}
+
+func TestBind(t *testing.T) {
+	k := Default()
+
+	if err := k.Bind(ContextGlobal, "save", []string{"ctrl+w", "f2"}); err != nil {
+		t.Fatal(err)
+	}
+	if keys := k.Keys(ContextGlobal, "save"); strings.Join(keys, ",") != "ctrl+w,f2" {
+		t.Errorf("rebinding should replace the old keys, got %v", keys)
+	}
+
+	err := k.Bind(ContextGlobal, "save", []string{"ctrl+q"})
+	if err == nil || !strings.Contains(err.Error(), `ctrl+q is already bound to "quit" in the global context`) {
+		t.Errorf("unexpected conflict error %v", err)
+	}
+	if err := k.Bind(ContextGlobal, "launch", []string{"f3"}); err == nil {
+		t.Error("unknown actions should be rejected")
+	}
+	if err := k.Bind(ContextModal, "save", []string{"f3"}); err == nil {
+		t.Error("save cannot be bound in dialogs")
+	}
+	if err := k.Bind(ContextList, "cancel", []string{"f3"}); err == nil {
+		t.Error("cancel can only be bound in dialogs")
+	}
+
+	k.Unbind(ContextList, "quit")
+	if err := k.Bind(ContextList, "help", []string{"q"}); err != nil {
+		t.Errorf("q should be free after unbinding quit: %v", err)
+	}
+}
+
+func TestDisplayAndHint(t *testing.T) {
+	tests := map[string]string{
+		"ctrl+s":  "Ctrl+S",
+		"backtab": "Shift+Tab",
+		"f1":      "F1",
+		"esc":     "Esc",
+		"?":       "?",
+		"alt+x":   "Alt+x",
+	}
+	for key, want := range tests {
+		if got := Display(key); got != want {
+			t.Errorf("Display(%q) = %q, want %q", key, got, want)
+		}
+	}
+
+	k := Default()
+	if hint := k.Hint("quit", ContextList, ContextGlobal); hint != "q" {
+		t.Errorf("quit hint = %q", hint)
+	}
+	if hint := k.Hint("quit", ContextForm, ContextGlobal); hint != "Ctrl+Q" {
+		t.Errorf("quit hint = %q", hint)
+	}
+}
+
+func TestHelp(t *testing.T) {
+	help := Default().Help(func(action string) bool { return action != "rename" })
+
+	for _, want := range []string{"Everywhere\n", "File list\n", "Dialogs\n", "ctrl+s", "Save the form"} {
+		if !strings.Contains(help, want) {
+			t.Errorf("help is missing %q:\n%s", want, help)
+		}
+	}
+	if strings.Contains(help, "Rename files") {
+		t.Error("unavailable actions should be left out")
+	}
+}
@@ -0,0 +1,193 @@
+package library
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"id3v2-tui/internal/metadata"
+)
+
+const indexVersion = 2
+
+var IndexPath string
+
+type record struct {
+	Size    int64
+	ModTime int64
+	Meta    metadata.Metadata
+	Tech    metadata.TechInfo
+	Cover   bool
+	Kind    string
+	Err     string
+}
+
+type indexFile struct {
+	Version int
+	Records map[string]record
+}
+
+type Index struct {
+	mu      sync.Mutex
+	path    string
+	records map[string]record
+	dirty   bool
+	hits    int
+	misses  int
+}
+
+func OpenIndex(path string) (*Index, error) {
+	ix := &Index{path: path, records: make(map[string]record)}
+	if path == "" {
+		return ix, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ix, nil
+	}
+	if err != nil {
+		return ix, err
+	}
+	defer f.Close()
+
+	var file indexFile
+	if err := gob.NewDecoder(f).Decode(&file); err != nil {
+		return ix, fmt.Errorf("%s: %w", path, err)
+	}
+	if file.Version == indexVersion && file.Records != nil {
+		ix.records = file.Records
+	}
+	return ix, nil
+}
+
+func (ix *Index) Load(path string) Entry {
+	if ix == nil {
+		return read(path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return Entry{Path: path, Meta: &metadata.Metadata{}, Err: err}
+	}
+
+	ix.mu.Lock()
+	r, ok := ix.records[path]
+	fresh := ok && r.Size == info.Size() && r.ModTime == info.ModTime().UnixNano()
+	if fresh {
+		ix.hits++
+	}
+	ix.mu.Unlock()
+	if fresh {
+		return r.entry(path)
+	}
+
+	e := read(path)
+	if e.Err != nil && !metadata.Overridable(e.Err) {
+		return e
+	}
+	r = record{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Meta: *e.Meta, Tech: e.Tech, Cover: e.Cover}
+	var readErr *metadata.ReadError
+	if errors.As(e.Err, &readErr) {
+		r.Kind = readErr.Kind.Error()
+		if readErr.Err != nil {
+			r.Err = readErr.Err.Error()
+		}
+	}
+	ix.mu.Lock()
+	ix.records[path] = r
+	ix.misses++
+	ix.dirty = true
+	ix.mu.Unlock()
+	return e
+}
+
+func (r record) entry(path string) Entry {
+	meta := r.Meta
+	e := Entry{Path: path, Meta: &meta, Tech: r.Tech, Cover: r.Cover}
+	if r.Kind != "" {
+		readErr := &metadata.ReadError{Path: path, Kind: errorKind(r.Kind)}
+		if r.Err != "" {
+			readErr.Err = errors.New(r.Err)
+		}
+		e.Err = readErr
+	}
+	return e
+}
+
+func errorKind(name string) error {
+	for _, kind := range []error{metadata.ErrCorruptHeader, metadata.ErrUnsupportedVersion, metadata.ErrNotAudio} {
+		if kind.Error() == name {
+			return kind
+		}
+	}
+	return errors.New(name)
+}
+
+func (ix *Index) Prune(dir string, recursive bool, keep []string) {
+	if ix == nil {
+		return
+	}
+	kept := make(map[string]bool, len(keep))
+	for _, path := range keep {
+		kept[path] = true
+	}
+	prefix := filepath.Clean(dir) + string(filepath.Separator)
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for path := range ix.records {
+		inside := filepath.Dir(path) == filepath.Clean(dir)
+		if recursive {
+			inside = strings.HasPrefix(path, prefix)
+		}
+		if inside && !kept[path] {
+			delete(ix.records, path)
+			ix.dirty = true
+		}
+	}
+}
+
+func (ix *Index) Stats() (records, hits, misses int) {
+	if ix == nil {
+		return 0, 0, 0
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	return len(ix.records), ix.hits, ix.misses
+}
+
+func (ix *Index) Save() error {
+	if ix == nil || ix.path == "" {
+		return nil
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if !ix.dirty {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(ix.path), 0o755); err != nil {
+		return err
+	}
+	tmp := ix.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	err = gob.NewEncoder(f).Encode(indexFile{Version: indexVersion, Records: ix.records})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, ix.path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	ix.dirty = false
+	return nil
+}
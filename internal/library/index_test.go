@@ -0,0 +1,125 @@
+package library
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"id3v2-tui/internal/metadata"
+)
+
+func TestIndex(t *testing.T) {
+	data, err := os.ReadFile("../../test/test-w-metadata-v2.mp3")
+	if err != nil {
+		t.Skip("test-w-metadata-v2.mp3 not found")
+	}
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.mp3"), filepath.Join(dir, "sub", "b.mp3")
+	os.MkdirAll(filepath.Dir(b), 0o755)
+	for _, path := range []string{a, b} {
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	indexPath := filepath.Join(t.TempDir(), "cache", "index.gob")
+
+	ix, err := OpenIndex(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := ix.Load(a)
+	ix.Load(b)
+	second := ix.Load(a)
+	if second.Meta.TrackName != "aoba" || second.Tech.Duration != first.Tech.Duration || second.Cover != first.Cover {
+		t.Errorf("cached entry differs: %+v vs %+v", second, first)
+	}
+	if records, hits, misses := ix.Stats(); records != 2 || hits != 1 || misses != 2 {
+		t.Errorf("unexpected stats %d records, %d hits, %d misses", records, hits, misses)
+	}
+	if err := ix.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	ix, err = OpenIndex(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix.Load(a)
+	if _, hits, _ := ix.Stats(); hits != 1 {
+		t.Error("expected the reopened index to serve a cached entry")
+	}
+
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(a, later, later); err != nil {
+		t.Fatal(err)
+	}
+	ix.Load(a)
+	if _, hits, misses := ix.Stats(); hits != 1 || misses != 1 {
+		t.Error("expected a changed modification time to invalidate the entry")
+	}
+
+	ix.Prune(dir, false, nil)
+	if records, _, _ := ix.Stats(); records != 1 {
+		t.Errorf("expected a shallow prune to keep the subdirectory, got %d records", records)
+	}
+	ix.Prune(dir, true, []string{a})
+	if records, _, _ := ix.Stats(); records != 0 {
+		t.Errorf("expected a recursive prune to drop everything not kept, got %d records", records)
+	}
+
+	if e := ix.Load(filepath.Join(dir, "missing.mp3")); e.Err == nil {
+		t.Error("expected an error for a missing file")
+	}
+
+	if err := os.WriteFile(indexPath, []byte("garbage"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if ix, err := OpenIndex(indexPath); err == nil || ix == nil {
+		t.Error("expected a corrupt index to report an error and still be usable")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	entries := []Entry{
+		{Meta: &metadata.Metadata{Artist: "A", Album: "X"}, Tech: metadata.TechInfo{Duration: time.Minute, FileSize: 10}, Cover: true},
+		{Meta: &metadata.Metadata{Artist: "a ", Album: "x"}, Tech: metadata.TechInfo{Duration: time.Minute, FileSize: 5}},
+		{Meta: &metadata.Metadata{Artist: "B", Album: "X"}},
+		{Meta: &metadata.Metadata{}, Err: metadata.ErrCorruptHeader},
+	}
+	s := Summarize(entries)
+	want := Stats{Files: 4, Unreadable: 1, NoCover: 2, Artists: 2, Albums: 2, Size: 15, Duration: 2 * time.Minute}
+	if s != want {
+		t.Errorf("expected %+v, got %+v", want, s)
+	}
+}
+
+func TestIndexKeepsErrorKind(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "noise.mp3")
+	if err := os.WriteFile(path, []byte("not an mp3 at all"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	indexPath := filepath.Join(t.TempDir(), "index.gob")
+
+	ix, _ := OpenIndex(indexPath)
+	if e := ix.Load(path); !errors.Is(e.Err, metadata.ErrNotAudio) {
+		t.Fatalf("expected a not-audio error, got %v", e.Err)
+	}
+	if err := ix.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	ix, _ = OpenIndex(indexPath)
+	e := ix.Load(path)
+	if _, hits, _ := ix.Stats(); hits != 1 {
+		t.Fatal("expected the error to be served from the index")
+	}
+	var readErr *metadata.ReadError
+	if !errors.As(e.Err, &readErr) || readErr.Path != path || !errors.Is(e.Err, metadata.ErrNotAudio) {
+		t.Errorf("expected a cached ReadError for %s, got %#v", path, e.Err)
+	}
+	if !metadata.Overridable(e.Err) {
+		t.Error("expected the cached error to stay overridable")
+	}
+}
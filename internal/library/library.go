@@ -0,0 +1,198 @@
+package library
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"id3v2-tui/internal/files"
+	"id3v2-tui/internal/metadata"
+)
+
+const Workers = 8
+
+var Columns = []string{"title", "artist", "album", "track", "year", "duration", "cover"}
+
+type Entry struct {
+	Path  string
+	Meta  *metadata.Metadata
+	Tech  metadata.TechInfo
+	Cover bool
+	Err   error
+}
+
+func read(path string) Entry {
+	entry := Entry{Path: path}
+	entry.Meta, entry.Err = metadata.Read(path)
+	if entry.Err != nil {
+		entry.Meta = &metadata.Metadata{}
+		return entry
+	}
+	if info, err := metadata.ReadTechInfo(path); err == nil {
+		entry.Tech = *info
+	}
+	if pictures, err := metadata.ReadPictures(path); err == nil {
+		entry.Cover = len(pictures) > 0
+	}
+	return entry
+}
+
+func Scan(ctx context.Context, ix *Index, paths []string, workers int, fn func(Entry)) {
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				fn(ix.Load(path))
+			}
+		}()
+	}
+
+feed:
+	for _, path := range paths {
+		select {
+		case jobs <- path:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func Stream(ctx context.Context, ix *Index, paths []string, workers int, interval time.Duration, fn func([]Entry)) {
+	entries := make(chan Entry)
+	go func() {
+		Scan(ctx, ix, paths, workers, func(e Entry) {
+			select {
+			case entries <- e:
+			case <-ctx.Done():
+			}
+		})
+		close(entries)
+	}()
+
+	var batch []Entry
+	flush := func() {
+		if len(batch) > 0 && ctx.Err() == nil {
+			fn(batch)
+		}
+		batch = nil
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case e, ok := <-entries:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, e)
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func FormatDuration(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	seconds := int(d.Round(time.Second).Seconds())
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+func (e Entry) Value(column string) string {
+	switch column {
+	case "duration":
+		return FormatDuration(e.Tech.Duration)
+	case "cover":
+		if e.Cover {
+			return "yes"
+		}
+		return ""
+	}
+	value, _ := e.Meta.Get(column)
+	return value
+}
+
+func (e Entry) Summary() string {
+	if e.Err != nil {
+		return "Unreadable tags"
+	}
+	parts := make([]string, 0, 2)
+	for _, column := range []string{"artist", "title"} {
+		if value := e.Value(column); value != "" {
+			parts = append(parts, value)
+		}
+	}
+	return strings.Join(parts, " - ")
+}
+
+func number(s string) (int, bool) {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "/")
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
+
+func Compare(a, b Entry, column string) int {
+	switch column {
+	case "duration":
+		return int(a.Tech.Duration - b.Tech.Duration)
+	case "cover":
+		switch {
+		case a.Cover == b.Cover:
+			return 0
+		case a.Cover:
+			return -1
+		}
+		return 1
+	case "track", "year":
+		na, okA := number(a.Value(column))
+		nb, okB := number(b.Value(column))
+		switch {
+		case okA && okB:
+			return na - nb
+		case okA != okB:
+			if okA {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	va, vb := strings.ToLower(a.Value(column)), strings.ToLower(b.Value(column))
+	switch {
+	case va == vb:
+		return 0
+	case va == "":
+		return 1
+	case vb == "":
+		return -1
+	case va < vb:
+		return -1
+	}
+	return 1
+}
+
+func Sort(entries []Entry, column string, descending bool) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if c := Compare(entries[i], entries[j], column); c != 0 {
+			return c < 0 != descending
+		}
+		return files.NaturalLess(entries[i].Path, entries[j].Path)
+	})
+}
@@ -0,0 +1,128 @@
+package library
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"id3v2-tui/internal/metadata"
+)
+
+func entry(path, title, track string, duration time.Duration, cover bool) Entry {
+	return Entry{Path: path, Meta: &metadata.Metadata{TrackName: title, Track: track}, Tech: metadata.TechInfo{Duration: duration}, Cover: cover}
+}
+
+func paths(entries []Entry) string {
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Path
+	}
+	return strings.Join(names, ",")
+}
+
+func TestSort(t *testing.T) {
+	entries := []Entry{
+		entry("a", "Zebra", "10/12", 3*time.Minute, false),
+		entry("b", "apple", "2/12", time.Minute, true),
+		entry("c", "", "1", 2*time.Minute, false),
+		entry("d", "Mango", "", 0, true),
+	}
+
+	tests := []struct {
+		column     string
+		descending bool
+		want       string
+	}{
+		{"title", false, "b,d,a,c"},
+		{"track", false, "c,b,a,d"},
+		{"track", true, "d,a,b,c"},
+		{"duration", false, "d,b,c,a"},
+		{"cover", false, "b,d,a,c"},
+	}
+	for _, tt := range tests {
+		Sort(entries, tt.column, tt.descending)
+		if got := paths(entries); got != tt.want {
+			t.Errorf("Sort(%s, %v) = %s, want %s", tt.column, tt.descending, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                               "",
+		59 * time.Second:                "0:59",
+		3*time.Minute + 5*time.Second:   "3:05",
+		time.Hour + 2*time.Minute + 3e9: "1:02:03",
+	}
+	for d, want := range tests {
+		if got := FormatDuration(d); got != want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	data, err := os.ReadFile("../../test/test-w-metadata-v2.mp3")
+	if err != nil {
+		t.Skip("test-w-metadata-v2.mp3 not found")
+	}
+	dir := t.TempDir()
+	var files []string
+	for i := range 20 {
+		path := filepath.Join(dir, string(rune('a'+i))+".mp3")
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+	files = append(files, filepath.Join(dir, "missing.mp3"))
+
+	var mu sync.Mutex
+	var entries []Entry
+	Scan(context.Background(), nil, files, 4, func(e Entry) {
+		mu.Lock()
+		entries = append(entries, e)
+		mu.Unlock()
+	})
+	if len(entries) != len(files) {
+		t.Fatalf("expected %d entries, got %d", len(files), len(entries))
+	}
+	failed := 0
+	for _, e := range entries {
+		if e.Err != nil {
+			failed++
+			continue
+		}
+		if e.Meta.TrackName != "aoba" || e.Tech.Duration == 0 {
+			t.Errorf("unexpected entry %+v", e)
+		}
+	}
+	if failed != 1 {
+		t.Errorf("expected one failed entry, got %d", failed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	count := 0
+	Scan(ctx, nil, files, 4, func(Entry) { count++ })
+	if count != 0 {
+		t.Errorf("a cancelled scan should not load files, got %d", count)
+	}
+
+	total := 0
+	Stream(context.Background(), nil, files, 4, time.Millisecond, func(batch []Entry) {
+		total += len(batch)
+	})
+	if total != len(files) {
+		t.Errorf("expected %d streamed entries, got %d", len(files), total)
+	}
+	Stream(ctx, nil, files, 4, time.Millisecond, func([]Entry) { t.Error("unexpected batch after cancel") })
+
+	if summary := entries[0].Summary(); summary != "pepega - aoba" && summary != "Unreadable tags" {
+		t.Errorf("unexpected summary %q", summary)
+	}
+}
@@ -0,0 +1,144 @@
+package library
+
+import (
+	"strings"
+
+	"id3v2-tui/internal/metadata"
+)
+
+type term struct {
+	negate bool
+	field  string
+	op     string
+	value  string
+}
+
+type Query struct {
+	text  string
+	terms []term
+}
+
+func ParseQuery(text string) Query {
+	q := Query{text: strings.TrimSpace(text)}
+	for _, token := range tokenize(q.text) {
+		t := term{}
+		if strings.HasPrefix(token, "!") && len(token) > 1 {
+			t.negate = true
+			token = token[1:]
+		}
+		field, value, ok := strings.Cut(token, ":")
+		field = strings.ToLower(field)
+		switch {
+		case !ok && strings.EqualFold(token, "cover"):
+			t.field = "cover"
+		case ok && (metadata.IsField(field) || field == "cover"):
+			t.field = field
+			t.op, t.value = operator(value)
+		default:
+			t.value = strings.ToLower(token)
+		}
+		q.terms = append(q.terms, t)
+	}
+	return q
+}
+
+func tokenize(text string) []string {
+	var tokens []string
+	var b strings.Builder
+	quoted := false
+	for _, r := range text {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ' ' && !quoted:
+			if b.Len() > 0 {
+				tokens = append(tokens, b.String())
+				b.Reset()
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() > 0 {
+		tokens = append(tokens, b.String())
+	}
+	return tokens
+}
+
+func operator(value string) (string, string) {
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if rest, ok := strings.CutPrefix(value, op); ok {
+			return op, rest
+		}
+	}
+	return "", strings.ToLower(value)
+}
+
+func (q Query) String() string {
+	return q.text
+}
+
+func (q Query) Empty() bool {
+	return len(q.terms) == 0
+}
+
+func (q Query) UsesTags() bool {
+	for _, t := range q.terms {
+		if t.field != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (q Query) Match(name string, e *Entry) bool {
+	for _, t := range q.terms {
+		if t.field != "" && (e == nil || e.Err != nil) {
+			return false
+		}
+		if t.match(name, e) == t.negate {
+			return false
+		}
+	}
+	return true
+}
+
+func (t term) match(name string, e *Entry) bool {
+	switch t.field {
+	case "":
+		return strings.Contains(strings.ToLower(name), t.value)
+	case "cover":
+		if t.op == "" && t.value != "" {
+			return e.Cover == (t.value == "yes" || t.value == "true")
+		}
+		return e.Cover
+	}
+
+	value := e.Value(t.field)
+	if t.op == "" {
+		return strings.Contains(strings.ToLower(value), t.value)
+	}
+	if value == "" {
+		return false
+	}
+
+	var c int
+	a, okA := number(value)
+	b, okB := number(t.value)
+	if okA && okB {
+		c = a - b
+	} else {
+		c = strings.Compare(strings.ToLower(value), strings.ToLower(t.value))
+	}
+	switch t.op {
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	}
+	return c == 0
+}
@@ -0,0 +1,49 @@
+package library
+
+import (
+	"testing"
+
+	"id3v2-tui/internal/metadata"
+)
+
+func TestQuery(t *testing.T) {
+	beatles := &Entry{Meta: &metadata.Metadata{Artist: "The Beatles", Year: "1967", Track: "3/13"}, Cover: true}
+	stones := &Entry{Meta: &metadata.Metadata{Artist: "The Rolling Stones", Year: "1964"}}
+	broken := &Entry{Meta: &metadata.Metadata{}, Err: metadata.ErrCorruptHeader}
+
+	tests := []struct {
+		query string
+		name  string
+		entry *Entry
+		want  bool
+	}{
+		{"", "song.mp3", nil, true},
+		{"SONG", "01 Song.mp3", nil, true},
+		{"song other", "01 Song.mp3", nil, false},
+		{"!live", "01 Song (Live).mp3", nil, false},
+		{"artist:beatles", "a.mp3", beatles, true},
+		{"artist:beatles", "a.mp3", stones, false},
+		{"artist:beatles", "a.mp3", nil, false},
+		{"artist:beatles", "a.mp3", broken, false},
+		{`artist:"rolling stones"`, "a.mp3", stones, true},
+		{"year:>1965", "a.mp3", beatles, true},
+		{"year:>1965", "a.mp3", stones, false},
+		{"year:<=1964", "a.mp3", stones, true},
+		{"track:=3", "a.mp3", beatles, true},
+		{"cover", "a.mp3", beatles, true},
+		{"!cover", "a.mp3", beatles, false},
+		{"!cover", "a.mp3", stones, true},
+		{"cover:no", "a.mp3", stones, true},
+		{"artist:beatles year:>1965 !cover", "a.mp3", beatles, false},
+		{"unknown:value", "unknown:value.mp3", nil, true},
+	}
+	for _, tt := range tests {
+		if got := ParseQuery(tt.query).Match(tt.name, tt.entry); got != tt.want {
+			t.Errorf("%q on %q: expected %v, got %v", tt.query, tt.name, tt.want, got)
+		}
+	}
+
+	if ParseQuery("song").UsesTags() || !ParseQuery("song !cover").UsesTags() {
+		t.Error("UsesTags reported the wrong result")
+	}
+}
@@ -0,0 +1,44 @@
+package library
+
+import (
+	"strings"
+	"time"
+)
+
+type Stats struct {
+	Files      int
+	Unreadable int
+	NoCover    int
+	Artists    int
+	Albums     int
+	Size       int64
+	Duration   time.Duration
+}
+
+func Summarize(entries []Entry) Stats {
+	var s Stats
+	artists := make(map[string]bool)
+	albums := make(map[string]bool)
+	for _, e := range entries {
+		s.Files++
+		if e.Err != nil {
+			s.Unreadable++
+			continue
+		}
+		if !e.Cover {
+			s.NoCover++
+		}
+		s.Size += e.Tech.FileSize
+		s.Duration += e.Tech.Duration
+		artist := strings.ToLower(strings.TrimSpace(e.Meta.Artist))
+		if artist != "" {
+			artists[artist] = true
+		}
+		if album := strings.ToLower(strings.TrimSpace(e.Meta.Album)); album != "" {
+			albums[artist+"\x00"+album] = true
+		}
+	}
+	s.Artists = len(artists)
+	s.Albums = len(albums)
+	return s
+}
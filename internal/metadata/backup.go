@@ -0,0 +1,63 @@
+package metadata
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	BackupNone   = "none"
+	BackupOnce   = "once"
+	BackupAlways = "always"
+)
+
+type BackupPolicy struct {
+	Mode string
+	Dir  string
+}
+
+var Backup = BackupPolicy{Mode: BackupNone}
+
+func BackupPath(filePath string) string {
+	if Backup.Dir == "" {
+		return filePath + ".bak"
+	}
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		abs = filePath
+	}
+	return filepath.Join(Backup.Dir, abs+".bak")
+}
+
+func backup(filePath string) error {
+	if Backup.Mode != BackupOnce && Backup.Mode != BackupAlways {
+		return nil
+	}
+
+	target := BackupPath(filePath)
+	if Backup.Mode == BackupOnce {
+		if _, err := os.Stat(target); err == nil {
+			return nil
+		}
+	}
+
+	src, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	dst, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
@@ -0,0 +1,304 @@
+package metadata
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bogem/id3v2"
+)
+
+type Picture struct {
+	Type        string
+	MimeType    string
+	Description string
+	Size        int
+	SHA256      string
+}
+
+type TechInfo struct {
+	FileSize   int64
+	TagVersion int
+	TagSize    int64
+	Bitrate    int
+	SampleRate int
+	Channels   int
+	VBR        bool
+	Duration   time.Duration
+}
+
+var pictureTypes = []string{
+	"Other", "File icon", "Other file icon", "Front cover", "Back cover",
+	"Leaflet page", "Media", "Lead artist", "Artist", "Conductor", "Band",
+	"Composer", "Lyricist", "Recording location", "During recording",
+	"During performance", "Screen capture", "Bright coloured fish",
+	"Illustration", "Band logotype", "Publisher logotype",
+}
+
+func pictureTypeName(t byte) string {
+	if int(t) < len(pictureTypes) {
+		return pictureTypes[t]
+	}
+	return fmt.Sprintf("Type %d", t)
+}
+
+func ReadPictures(filePath string) ([]Picture, error) {
+	tag, err := openTag(filePath, id3v2.Options{Parse: true, ParseFrames: []string{"Attached picture"}})
+	if err != nil {
+		return nil, err
+	}
+	defer tag.Close()
+
+	var pictures []Picture
+	for _, frame := range tag.GetFrames(tag.CommonID("Attached picture")) {
+		pf, ok := frame.(id3v2.PictureFrame)
+		if !ok {
+			continue
+		}
+		sum := sha256.Sum256(pf.Picture)
+		pictures = append(pictures, Picture{
+			Type:        pictureTypeName(pf.PictureType),
+			MimeType:    pf.MimeType,
+			Description: pf.Description,
+			Size:        len(pf.Picture),
+			SHA256:      hex.EncodeToString(sum[:]),
+		})
+	}
+	return pictures, nil
+}
+
+var (
+	mpegBitrates = map[int][16]int{
+		1: {0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+		2: {0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+	}
+	mpegSampleRates = map[int][3]int{
+		1: {44100, 48000, 32000},
+		2: {22050, 24000, 16000},
+		3: {11025, 12000, 8000},
+	}
+)
+
+type frameHeader struct {
+	version    int
+	bitrate    int
+	sampleRate int
+	channels   int
+	samples    int
+	size       int
+}
+
+func parseFrameHeader(b []byte) (frameHeader, bool) {
+	if len(b) < 4 || b[0] != 0xFF || b[1]&0xE0 != 0xE0 {
+		return frameHeader{}, false
+	}
+
+	var h frameHeader
+	switch (b[1] >> 3) & 0x03 {
+	case 3:
+		h.version = 1
+	case 2:
+		h.version = 2
+	case 0:
+		h.version = 3
+	default:
+		return frameHeader{}, false
+	}
+	if (b[1]>>1)&0x03 != 1 {
+		return frameHeader{}, false
+	}
+
+	bitrateIndex := int(b[2] >> 4)
+	sampleRateIndex := int((b[2] >> 2) & 0x03)
+	if bitrateIndex == 0 || bitrateIndex == 15 || sampleRateIndex == 3 {
+		return frameHeader{}, false
+	}
+
+	table := 1
+	if h.version != 1 {
+		table = 2
+	}
+	h.bitrate = mpegBitrates[table][bitrateIndex]
+	h.sampleRate = mpegSampleRates[h.version][sampleRateIndex]
+	h.channels = 2
+	if b[3]>>6 == 3 {
+		h.channels = 1
+	}
+
+	padding := int((b[2] >> 1) & 0x01)
+	if h.version == 1 {
+		h.samples = 1152
+		h.size = 144*h.bitrate*1000/h.sampleRate + padding
+	} else {
+		h.samples = 576
+		h.size = 72*h.bitrate*1000/h.sampleRate + padding
+	}
+	return h, true
+}
+
+func xingOffset(h frameHeader) int {
+	switch {
+	case h.version == 1 && h.channels == 2:
+		return 36
+	case h.version == 1, h.channels == 2:
+		return 21
+	default:
+		return 13
+	}
+}
+
+func id3v2Size(header []byte) (int64, int) {
+	if len(header) < 10 || !bytes.Equal(header[:3], []byte("ID3")) {
+		return 0, 0
+	}
+	size := int64(header[6])<<21 | int64(header[7])<<14 | int64(header[8])<<7 | int64(header[9])
+	size += 10
+	if header[5]&0x10 != 0 {
+		size += 10
+	}
+	return size, int(header[3])
+}
+
+func ReadTechInfo(filePath string) (*TechInfo, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	info := &TechInfo{FileSize: stat.Size()}
+
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(f, header); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return info, nil
+	}
+	info.TagSize, info.TagVersion = id3v2Size(header)
+
+	if _, err := f.Seek(info.TagSize, io.SeekStart); err != nil {
+		return nil, err
+	}
+	padding, err := skipPadding(f)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 64*1024)
+	n, _ := io.ReadFull(f, buf)
+	buf = buf[:n]
+
+	for i := 0; i+4 <= len(buf); i++ {
+		h, ok := parseFrameHeader(buf[i:])
+		if !ok {
+			continue
+		}
+		next := i + h.size
+		if next+4 <= len(buf) {
+			if _, ok := parseFrameHeader(buf[next:]); !ok {
+				continue
+			}
+		}
+
+		info.SampleRate = h.sampleRate
+		info.Channels = h.channels
+		info.Bitrate = h.bitrate
+
+		audioSize := info.FileSize - info.TagSize - padding - int64(i)
+		if frames, ok := xingFrames(buf[i:], h); ok && frames > 0 {
+			info.VBR = true
+			seconds := float64(frames) * float64(h.samples) / float64(h.sampleRate)
+			info.Duration = time.Duration(seconds * float64(time.Second))
+			if seconds > 0 {
+				info.Bitrate = int(float64(audioSize) * 8 / seconds / 1000)
+			}
+		} else if h.bitrate > 0 {
+			info.Duration = time.Duration(float64(audioSize) * 8 / float64(h.bitrate*1000) * float64(time.Second))
+		}
+		break
+	}
+
+	return info, nil
+}
+
+func skipPadding(f *os.File) (int64, error) {
+	start, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	buf := make([]byte, 4096)
+	var skipped int64
+	for {
+		n, err := f.Read(buf)
+		for i, b := range buf[:n] {
+			if b != 0 {
+				skipped += int64(i)
+				_, err := f.Seek(start+skipped, io.SeekStart)
+				return skipped, err
+			}
+		}
+		skipped += int64(n)
+		if err != nil {
+			_, err := f.Seek(start+skipped, io.SeekStart)
+			return skipped, err
+		}
+	}
+}
+
+func xingFrames(frame []byte, h frameHeader) (uint32, bool) {
+	offset := xingOffset(h)
+	if len(frame) < offset+12 {
+		return 0, false
+	}
+	id := string(frame[offset : offset+4])
+	if id != "Xing" && id != "Info" {
+		return 0, false
+	}
+	flags := binary.BigEndian.Uint32(frame[offset+4:])
+	if flags&0x01 == 0 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(frame[offset+8:]), true
+}
+
+func ReadCover(filePath string) ([]byte, string, error) {
+	tag, err := openTag(filePath, id3v2.Options{Parse: true, ParseFrames: []string{"Attached picture"}})
+	if err != nil {
+		return nil, "", err
+	}
+	defer tag.Close()
+
+	var cover *id3v2.PictureFrame
+	for _, frame := range tag.GetFrames(tag.CommonID("Attached picture")) {
+		pf, ok := frame.(id3v2.PictureFrame)
+		if !ok {
+			continue
+		}
+		if cover == nil || pf.PictureType == id3v2.PTFrontCover && cover.PictureType != id3v2.PTFrontCover {
+			cover = &pf
+		}
+	}
+	if cover == nil {
+		return nil, "", errors.New("no cover picture")
+	}
+	return cover.Picture, cover.MimeType, nil
+}
+
+func PictureExtension(mimeType string) string {
+	switch strings.ToLower(mimeType) {
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	default:
+		return ".jpg"
+	}
+}
@@ -0,0 +1,146 @@
+package metadata
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/bogem/id3v2"
+)
+
+var (
+	ErrPermission         = errors.New("permission denied")
+	ErrCorruptHeader      = errors.New("corrupt tag header")
+	ErrUnsupportedVersion = errors.New("unsupported tag version")
+	ErrNotAudio           = errors.New("not an audio file")
+)
+
+type ReadError struct {
+	Path string
+	Kind error
+	Err  error
+}
+
+func (e *ReadError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%s: %s", e.Path, e.Kind)
+	}
+	return fmt.Sprintf("%s: %s (%s)", e.Path, e.Kind, e.Err)
+}
+
+func (e *ReadError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+func Overridable(err error) bool {
+	return errors.Is(err, ErrCorruptHeader) || errors.Is(err, ErrUnsupportedVersion) || errors.Is(err, ErrNotAudio)
+}
+
+func openTag(filePath string, opts id3v2.Options) (*id3v2.Tag, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrPermission) {
+			return nil, &ReadError{filePath, ErrPermission, nil}
+		}
+		return nil, err
+	}
+
+	if err := sniff(filePath, f); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	tag, err := id3v2.ParseReader(f, opts)
+	if err != nil {
+		f.Close()
+		kind := ErrCorruptHeader
+		if errors.Is(err, id3v2.ErrUnsupportedVersion) {
+			kind = ErrUnsupportedVersion
+		}
+		return nil, &ReadError{filePath, kind, err}
+	}
+	return tag, nil
+}
+
+func sniff(filePath string, f *os.File) error {
+	header := make([]byte, 10)
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		if errors.Is(err, fs.ErrPermission) {
+			return &ReadError{filePath, ErrPermission, nil}
+		}
+		return err
+	}
+	header = header[:n]
+
+	var offset int64
+	if n >= 3 && string(header[:3]) == "ID3" {
+		if n < 10 {
+			return &ReadError{filePath, ErrCorruptHeader, errors.New("truncated header")}
+		}
+		if header[3] < 3 || header[3] > 4 {
+			return &ReadError{filePath, ErrUnsupportedVersion, fmt.Errorf("ID3v2.%d", header[3])}
+		}
+		for _, b := range header[6:10] {
+			if b&0x80 != 0 {
+				return &ReadError{filePath, ErrCorruptHeader, errors.New("invalid tag size")}
+			}
+		}
+		offset, _ = id3v2Size(header)
+	}
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	if !hasAudioFrame(f) {
+		return &ReadError{filePath, ErrNotAudio, nil}
+	}
+
+	_, err = f.Seek(0, io.SeekStart)
+	return err
+}
+
+const maxAudioScan = 1 << 20
+
+func hasAudioFrame(r io.Reader) bool {
+	br := bufio.NewReaderSize(r, 64*1024)
+	for scanned := 0; scanned < maxAudioScan; {
+		b, err := br.Peek(4)
+		if err != nil {
+			return false
+		}
+		if isFrameSync(b) {
+			return true
+		}
+		if b[0] != 0 {
+			scanned++
+		}
+		br.Discard(1)
+	}
+	return false
+}
+
+func isFrameSync(b []byte) bool {
+	return b[0] == 0xFF && b[1]&0xE0 == 0xE0 &&
+		(b[1]>>3)&0x03 != 1 && (b[1]>>1)&0x03 != 0 &&
+		b[2]>>4 != 15 && (b[2]>>2)&0x03 != 3
+}
+
+func withoutTag(filePath string, data []byte) ([]byte, error) {
+	if len(data) < 10 || string(data[:3]) != "ID3" {
+		return data, nil
+	}
+	for _, b := range data[6:10] {
+		if b&0x80 != 0 {
+			return nil, &ReadError{filePath, ErrCorruptHeader, errors.New("unknown tag size")}
+		}
+	}
+	size, _ := id3v2Size(data[:10])
+	return data[min(size, int64(len(data))):], nil
+}
@@ -1,6 +1,7 @@
 package metadata
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,10 +11,160 @@ import (
 )
 
 type Metadata struct {
-	TrackName string
-	Artist    string
-	Album     string
-	CoverPath string
+	TrackName   string
+	Artist      string
+	Album       string
+	AlbumArtist string
+	Year        string
+	Track       string
+	Disc        string
+	Comment     string
+	CoverPath   string
+}
+
+type field struct {
+	name    string
+	label   string
+	frameID func(tag *id3v2.Tag) string
+	comment bool
+	value   func(m *Metadata) *string
+}
+
+var fields = []field{
+	{"title", "Track", commonID("Title"), false, func(m *Metadata) *string { return &m.TrackName }},
+	{"artist", "Artist", commonID("Artist"), false, func(m *Metadata) *string { return &m.Artist }},
+	{"album", "Album", commonID("Album/Movie/Show title"), false, func(m *Metadata) *string { return &m.Album }},
+	{"albumartist", "Album Artist", commonID("Band/Orchestra/Accompaniment"), false, func(m *Metadata) *string { return &m.AlbumArtist }},
+	{"year", "Year", commonID("Year"), false, func(m *Metadata) *string { return &m.Year }},
+	{"track", "Track Number", commonID("Track number/Position in set"), false, func(m *Metadata) *string { return &m.Track }},
+	{"disc", "Disc Number", commonID("Part of a set"), false, func(m *Metadata) *string { return &m.Disc }},
+	{"comment", "Comment", commonID("Comments"), true, func(m *Metadata) *string { return &m.Comment }},
+}
+
+func commonID(description string) func(tag *id3v2.Tag) string {
+	return func(tag *id3v2.Tag) string {
+		return tag.CommonID(description)
+	}
+}
+
+func (f field) commentFrame(tag *id3v2.Tag) (id3v2.CommentFrame, bool) {
+	var found id3v2.CommentFrame
+	ok := false
+	for _, frame := range tag.GetFrames(f.frameID(tag)) {
+		cf, isComment := frame.(id3v2.CommentFrame)
+		if !isComment {
+			continue
+		}
+		if !ok || (found.Description != "" && cf.Description == "") {
+			found, ok = cf, true
+		}
+	}
+	return found, ok
+}
+
+func (f field) read(tag *id3v2.Tag) (string, id3v2.Encoding) {
+	if f.comment {
+		cf, _ := f.commentFrame(tag)
+		return cf.Text, cf.Encoding
+	}
+	tf := tag.GetTextFrame(f.frameID(tag))
+	return tf.Text, tf.Encoding
+}
+
+func (f field) write(tag *id3v2.Tag, encoding id3v2.Encoding, value string) {
+	if f.comment {
+		cf, ok := f.commentFrame(tag)
+		if !ok {
+			cf = id3v2.CommentFrame{Language: "eng"}
+		}
+		cf.Encoding = encoding
+		cf.Text = value
+		tag.AddCommentFrame(cf)
+		return
+	}
+	tag.AddTextFrame(f.frameID(tag), encoding, value)
+}
+
+func Fields() []string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.name
+	}
+	return names
+}
+
+func lookupField(name string) (field, bool) {
+	name = strings.ToLower(name)
+	for _, f := range fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+func IsField(name string) bool {
+	_, ok := lookupField(name)
+	return ok
+}
+
+func ParseFields(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return Fields(), nil
+	}
+
+	var fields []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !IsField(name) {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		fields = append(fields, name)
+	}
+	return fields, nil
+}
+
+func (m *Metadata) Get(name string) (string, bool) {
+	f, ok := lookupField(name)
+	if !ok {
+		return "", false
+	}
+	return *f.value(m), true
+}
+
+func (m *Metadata) Set(name, value string) bool {
+	f, ok := lookupField(name)
+	if !ok {
+		return false
+	}
+	*f.value(m) = value
+	return true
+}
+
+func (m *Metadata) Clone() *Metadata {
+	c := *m
+	return &c
+}
+
+func Merge(metas []*Metadata) (*Metadata, []string) {
+	merged := &Metadata{}
+	var mixed []string
+	for _, f := range fields {
+		for i, m := range metas {
+			value := *f.value(m)
+			if i == 0 {
+				*f.value(merged) = value
+			} else if *f.value(merged) != value {
+				*f.value(merged) = ""
+				mixed = append(mixed, f.name)
+				break
+			}
+		}
+	}
+	return merged, mixed
 }
 
 func formatValue(v string) string {
@@ -23,17 +174,25 @@ func formatValue(v string) string {
 	return v
 }
 
+func formatNewValue(old, v string) string {
+	if v == "" && old != "" {
+		return "(removed)"
+	}
+	return formatValue(v)
+}
+
 func (m *Metadata) Diff(other *Metadata) string {
 	var changes []string
 
-	if m.TrackName != other.TrackName {
-		changes = append(changes, fmt.Sprintf("Track: %s → %s", formatValue(m.TrackName), formatValue(other.TrackName)))
-	}
-	if m.Artist != other.Artist {
-		changes = append(changes, fmt.Sprintf("Artist: %s → %s", formatValue(m.Artist), formatValue(other.Artist)))
+	for _, f := range fields {
+		oldValue, newValue := *f.value(m), *f.value(other)
+		if oldValue != newValue {
+			changes = append(changes, fmt.Sprintf("%s: %s → %s", f.label, formatValue(oldValue), formatNewValue(oldValue, newValue)))
+		}
 	}
-	if m.Album != other.Album {
-		changes = append(changes, fmt.Sprintf("Album: %s → %s", formatValue(m.Album), formatValue(other.Album)))
+
+	if m.CoverPath != other.CoverPath {
+		changes = append(changes, fmt.Sprintf("Cover: %s → %s", formatValue(m.CoverPath), formatValue(other.CoverPath)))
 	}
 
 	if len(changes) == 0 {
@@ -43,18 +202,86 @@ func (m *Metadata) Diff(other *Metadata) string {
 	return strings.Join(changes, "\n")
 }
 
+type Change struct {
+	Label string
+	Old   string
+	New   string
+}
+
+func (m *Metadata) Changes(other *Metadata) []Change {
+	var changes []Change
+	for _, f := range fields {
+		if oldValue, newValue := *f.value(m), *f.value(other); oldValue != newValue {
+			changes = append(changes, Change{f.label, oldValue, newValue})
+		}
+	}
+	if m.CoverPath != other.CoverPath {
+		changes = append(changes, Change{"Cover", m.CoverPath, other.CoverPath})
+	}
+	return changes
+}
+
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+func PlanSave(filePath string, meta *Metadata) ([]Change, error) {
+	tag, err := openTag(filePath, id3v2.Options{Parse: true})
+	if Overridable(err) {
+		tag, err = id3v2.NewEmptyTag(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer tag.Close()
+
+	if !tag.HasFrames() {
+		tag.SetVersion(DefaultVersion)
+	}
+
+	var changes []Change
+	for _, f := range fields {
+		old, _ := f.read(tag)
+		if value := *f.value(meta); value != old {
+			changes = append(changes, Change{f.label, old, value})
+		}
+	}
+
+	if meta.CoverPath != "" {
+		info, err := os.Stat(meta.CoverPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cover file: %w", err)
+		}
+		change := Change{Label: "Cover"}
+		for _, frame := range tag.GetFrames(tag.CommonID("Attached picture")) {
+			if pf, ok := frame.(id3v2.PictureFrame); ok && pf.Description == "Front cover" {
+				change.Old = fmt.Sprintf("%s, %s, %s (replaced)", pictureTypeName(pf.PictureType), pf.MimeType, formatSize(int64(len(pf.Picture))))
+			}
+		}
+		change.New = fmt.Sprintf("%s, %s, %s", filepath.Base(meta.CoverPath), getMimeType(meta.CoverPath), formatSize(info.Size()))
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
 func Read(filePath string) (*Metadata, error) {
-	tag, err := id3v2.Open(filePath, id3v2.Options{Parse: true})
+	tag, err := openTag(filePath, id3v2.Options{Parse: true})
 	if err != nil {
-		return &Metadata{}, nil
+		return nil, err
 	}
 	defer tag.Close()
 
-	return &Metadata{
-		TrackName: tag.Title(),
-		Artist:    tag.Artist(),
-		Album:     tag.Album(),
-	}, nil
+	meta := &Metadata{}
+	for _, f := range fields {
+		*f.value(meta), _ = f.read(tag)
+	}
+	return meta, nil
 }
 
 func getMimeType(path string) string {
@@ -71,21 +298,62 @@ func getMimeType(path string) string {
 	}
 }
 
+var (
+	DefaultVersion byte = 4
+	PreferUTF16         = false
+)
+
+func textEncoding(tag *id3v2.Tag) id3v2.Encoding {
+	if tag.Version() == 4 && !PreferUTF16 {
+		return id3v2.EncodingUTF8
+	}
+	return id3v2.EncodingUTF16
+}
+
 func Save(filePath string, meta *Metadata) error {
-	tag, err := id3v2.Open(filePath, id3v2.Options{Parse: true})
+	return save(filePath, meta, false)
+}
+
+func Overwrite(filePath string, meta *Metadata) error {
+	return save(filePath, meta, true)
+}
+
+func save(filePath string, meta *Metadata, overwrite bool) error {
+	if err := backup(filePath); err != nil {
+		return fmt.Errorf("failed to back up file: %w", err)
+	}
+	if overwrite {
+		return replaceTag(filePath, meta)
+	}
+
+	tag, err := openTag(filePath, id3v2.Options{Parse: true})
 	if err != nil {
 		return fmt.Errorf("failed to open file: %w", err)
 	}
 	defer tag.Close()
 
-	if meta.TrackName != "" {
-		tag.SetTitle(meta.TrackName)
+	if !tag.HasFrames() {
+		tag.SetVersion(DefaultVersion)
 	}
-	if meta.Artist != "" {
-		tag.SetArtist(meta.Artist)
+	if err := setFrames(tag, meta); err != nil {
+		return err
+	}
+
+	if err := tag.Save(); err != nil {
+		return fmt.Errorf("failed to save metadata: %w", err)
 	}
-	if meta.Album != "" {
-		tag.SetAlbum(meta.Album)
+
+	return nil
+}
+
+func setFrames(tag *id3v2.Tag, meta *Metadata) error {
+	encoding := textEncoding(tag)
+	for _, f := range fields {
+		if value := *f.value(meta); value == "" {
+			tag.DeleteFrames(f.frameID(tag))
+		} else {
+			f.write(tag, encoding, value)
+		}
 	}
 
 	if meta.CoverPath != "" {
@@ -103,6 +371,129 @@ func Save(filePath string, meta *Metadata) error {
 		}
 		tag.AddAttachedPicture(pic)
 	}
+	return nil
+}
+
+func replaceTag(filePath string, meta *Metadata) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	audio, err := withoutTag(filePath, data)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+
+	tag := id3v2.NewEmptyTag()
+	tag.SetVersion(DefaultVersion)
+	if err := setFrames(tag, meta); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if _, err := tag.WriteTo(&buf); err != nil {
+		return fmt.Errorf("failed to save metadata: %w", err)
+	}
+	buf.Write(audio)
+	if err := os.WriteFile(filePath, buf.Bytes(), info.Mode()); err != nil {
+		return fmt.Errorf("failed to save metadata: %w", err)
+	}
+	return nil
+}
+
+func withoutUTF8(encoding id3v2.Encoding) id3v2.Encoding {
+	if encoding.Equals(id3v2.EncodingUTF8) {
+		return id3v2.EncodingUTF16
+	}
+	return encoding
+}
+
+func downgradeEncoding(frame id3v2.Framer) id3v2.Framer {
+	switch f := frame.(type) {
+	case id3v2.TextFrame:
+		f.Encoding = withoutUTF8(f.Encoding)
+		return f
+	case id3v2.CommentFrame:
+		f.Encoding = withoutUTF8(f.Encoding)
+		return f
+	case id3v2.PictureFrame:
+		f.Encoding = withoutUTF8(f.Encoding)
+		return f
+	case id3v2.UnsynchronisedLyricsFrame:
+		f.Encoding = withoutUTF8(f.Encoding)
+		return f
+	case id3v2.UserDefinedTextFrame:
+		f.Encoding = withoutUTF8(f.Encoding)
+		return f
+	}
+	return frame
+}
+
+func ConvertVersion(filePath string, version byte) error {
+	if version != 3 && version != 4 {
+		return fmt.Errorf("unsupported ID3v2 version %d", version)
+	}
+	if err := backup(filePath); err != nil {
+		return fmt.Errorf("failed to back up file: %w", err)
+	}
+
+	tag, err := openTag(filePath, id3v2.Options{Parse: true})
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer tag.Close()
+
+	if tag.Version() == version {
+		return nil
+	}
+
+	year, _ := lookupField("year")
+	value, _ := year.read(tag)
+	tag.DeleteFrames(year.frameID(tag))
+	tag.SetVersion(version)
+
+	encoding := textEncoding(tag)
+	for id, frames := range tag.AllFrames() {
+		tag.DeleteFrames(id)
+		for _, frame := range frames {
+			if version == 3 {
+				frame = downgradeEncoding(frame)
+			}
+			tag.AddFrame(id, frame)
+		}
+	}
+	if value != "" {
+		year.write(tag, encoding, value)
+	}
+
+	if err := tag.Save(); err != nil {
+		return fmt.Errorf("failed to save metadata: %w", err)
+	}
+	return nil
+}
+
+func Clear(filePath string, names []string) error {
+	if err := backup(filePath); err != nil {
+		return fmt.Errorf("failed to back up file: %w", err)
+	}
+
+	tag, err := openTag(filePath, id3v2.Options{Parse: true})
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer tag.Close()
+
+	for _, name := range names {
+		f, ok := lookupField(name)
+		if !ok {
+			return fmt.Errorf("unknown field %q", name)
+		}
+		tag.DeleteFrames(f.frameID(tag))
+	}
 
 	if err := tag.Save(); err != nil {
 		return fmt.Errorf("failed to save metadata: %w", err)
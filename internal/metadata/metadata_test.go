@@ -1,7 +1,9 @@
 package metadata
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -266,21 +268,89 @@ func TestSaveWithJPGCover(t *testing.T) {
 
 func TestReadNonExistentFile(t *testing.T) {
 	meta, err := Read("./nonexistent-file.mp3")
-	if err != nil {
-		t.Errorf("expected no error for non-existent file, got: %v", err)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected a not-exist error, got: %v", err)
 	}
-	if meta == nil {
-		t.Error("expected metadata to be returned even on error")
+	if meta != nil {
+		t.Error("expected no metadata on error")
 	}
-	if meta.TrackName != "" || meta.Artist != "" || meta.Album != "" {
-		t.Error("expected empty metadata for non-existent file")
+}
+
+func TestReadErrors(t *testing.T) {
+	frame := append([]byte{0xFF, 0xFB, 0x90, 0x00}, make([]byte, 412)...)
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"text.mp3", []byte("just some text, not audio"), ErrNotAudio},
+		{"empty.mp3", nil, ErrNotAudio},
+		{"v22.mp3", append([]byte("ID3\x02\x00\x00\x00\x00\x00\x00"), frame...), ErrUnsupportedVersion},
+		{"v25.mp3", append([]byte("ID3\x05\x00\x00\x00\x00\x00\x00"), frame...), ErrUnsupportedVersion},
+		{"size.mp3", append([]byte("ID3\x04\x00\x00\x80\x00\x00\x00"), frame...), ErrCorruptHeader},
+		{"short.mp3", []byte("ID3\x04\x00"), ErrCorruptHeader},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(path, tt.data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := Read(path)
+		var readErr *ReadError
+		if !errors.As(err, &readErr) || !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+		if !Overridable(err) {
+			t.Errorf("%s: expected an overridable error", tt.name)
+		}
+	}
+
+	path := filepath.Join(dir, "locked.mp3")
+	if err := os.WriteFile(path, frame, 0o000); err != nil {
+		t.Fatal(err)
+	}
+	if f, err := os.Open(path); err == nil {
+		f.Close()
+		t.Skip("running with permission to read any file")
+	}
+	if _, err := Read(path); !errors.Is(err, ErrPermission) || Overridable(err) {
+		t.Errorf("expected a permission error, got %v", err)
+	}
+}
+
+func TestReadPaddedAndOtherLayers(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"padded.mp3", append(append([]byte("ID3\x04\x00\x00\x00\x00\x00\x00"), make([]byte, 100*1024)...), 0xFF, 0xFB, 0x90, 0x00)},
+		{"layer2.mp2", append([]byte{0xFF, 0xFD, 0x90, 0x00}, make([]byte, 412)...)},
+		{"layer1.mp1", append([]byte{0xFF, 0xFF, 0x90, 0x00}, make([]byte, 412)...)},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(path, tt.data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Read(path); err != nil {
+			t.Errorf("%s: expected the file to be read, got %v", tt.name, err)
+		}
+	}
+
+	info, err := ReadTechInfo(filepath.Join(dir, "padded.mp3"))
+	if err != nil || info.SampleRate != 44100 || info.Bitrate != 128 {
+		t.Errorf("expected the frame after the padding to be found, got %+v, %v", info, err)
 	}
 }
 
 func TestReadFromFileWithMetadata(t *testing.T) {
-	testFileWithMeta := "./../../test/test-w-metadata.mp3"
+	testFileWithMeta := "./../../test/test-w-metadata-v2.mp3"
 	if _, err := os.Stat(testFileWithMeta); os.IsNotExist(err) {
-		t.Skip("test-w-metadata.mp3 not found")
+		t.Skip("test-w-metadata-v2.mp3 not found")
 	}
 
 	meta, err := Read(testFileWithMeta)
@@ -288,11 +358,470 @@ func TestReadFromFileWithMetadata(t *testing.T) {
 		t.Fatalf("Read failed: %v", err)
 	}
 
-	if meta == nil {
-		t.Fatal("metadata is nil")
+	if meta.TrackName != "aoba" || meta.Artist != "pepega" || meta.Album != "amogus" {
+		t.Errorf("unexpected metadata %+v", meta)
+	}
+}
+
+func TestOverwriteCorruptTag(t *testing.T) {
+	data, err := os.ReadFile("./../../test/test-w-metadata.mp3")
+	if err != nil {
+		t.Skip("test-w-metadata.mp3 not found")
+	}
+	path := filepath.Join(t.TempDir(), "corrupt.mp3")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Read(path); !errors.Is(err, ErrCorruptHeader) {
+		t.Fatalf("expected a corrupt tag, got %v", err)
+	}
+	if err := Save(path, &Metadata{TrackName: "Fixed"}); !errors.Is(err, ErrCorruptHeader) {
+		t.Errorf("Save should refuse a corrupt tag, got %v", err)
+	}
+
+	if err := Overwrite(path, &Metadata{TrackName: "Fixed"}); err != nil {
+		t.Fatalf("Overwrite failed: %v", err)
+	}
+	meta, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read after Overwrite failed: %v", err)
+	}
+	if meta.TrackName != "Fixed" || meta.Artist != "" {
+		t.Errorf("unexpected metadata %+v", meta)
+	}
+	info, err := ReadTechInfo(path)
+	if err != nil || info.Duration == 0 {
+		t.Errorf("audio should survive Overwrite, got %+v, %v", info, err)
+	}
+}
+
+func TestGetSetField(t *testing.T) {
+	meta := &Metadata{}
+
+	for _, name := range Fields() {
+		if !meta.Set(name, "value-"+name) {
+			t.Fatalf("Set(%q) failed", name)
+		}
+		value, ok := meta.Get(name)
+		if !ok || value != "value-"+name {
+			t.Errorf("Get(%q) = %q, %v", name, value, ok)
+		}
+	}
+
+	if meta.Set("unknown", "x") {
+		t.Error("expected Set to fail for unknown field")
+	}
+	if _, ok := meta.Get("unknown"); ok {
+		t.Error("expected Get to fail for unknown field")
+	}
+}
+
+func TestDiffExtendedFields(t *testing.T) {
+	m1 := &Metadata{Year: "1969", Track: "1"}
+	m2 := &Metadata{Year: "1970", Track: "1", AlbumArtist: "Band"}
+
+	diff := m1.Diff(m2)
+	if !contains(diff, "Year: 1969 → 1970") {
+		t.Errorf("expected year change in diff, got '%s'", diff)
+	}
+	if !contains(diff, "Album Artist: (empty) → Band") {
+		t.Errorf("expected album artist change in diff, got '%s'", diff)
+	}
+	if contains(diff, "Track Number") {
+		t.Errorf("expected no track number change in diff, got '%s'", diff)
+	}
+}
+
+func TestSaveExtendedFields(t *testing.T) {
+	setupTestFile(t)
+	clearMetadata(t)
+
+	meta := &Metadata{
+		TrackName:   "Song",
+		AlbumArtist: "Album Artist",
+		Year:        "2001",
+		Track:       "3/12",
+	}
+	if err := Save(testFile, meta); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	readMeta, err := Read(testFile)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if readMeta.AlbumArtist != "Album Artist" || readMeta.Year != "2001" || readMeta.Track != "3/12" {
+		t.Errorf("unexpected metadata after save: %+v", readMeta)
+	}
+
+	clearMetadata(t)
+}
+
+func TestParseFields(t *testing.T) {
+	fields, err := ParseFields("Title, artist")
+	if err != nil {
+		t.Fatalf("ParseFields failed: %v", err)
+	}
+	if len(fields) != 2 || fields[0] != "title" || fields[1] != "artist" {
+		t.Errorf("unexpected fields %v", fields)
+	}
+
+	all, err := ParseFields("")
+	if err != nil {
+		t.Fatalf("ParseFields failed: %v", err)
+	}
+	if len(all) != len(Fields()) {
+		t.Errorf("expected all fields, got %v", all)
+	}
+
+	if _, err := ParseFields("title,bogus"); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
+
+func TestSaveComment(t *testing.T) {
+	setupTestFile(t)
+	clearMetadata(t)
+
+	if err := Save(testFile, &Metadata{Comment: "First"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := Save(testFile, &Metadata{Comment: "Second"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	tag, err := id3v2.Open(testFile, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	count := len(tag.GetFrames(tag.CommonID("Comments")))
+	tag.Close()
+	if count != 1 {
+		t.Errorf("expected comment to be replaced, got %d frames", count)
+	}
+
+	readMeta, err := Read(testFile)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if readMeta.Comment != "Second" {
+		t.Errorf("expected 'Second', got '%s'", readMeta.Comment)
+	}
+
+	clearMetadata(t)
+}
+
+func TestClearFields(t *testing.T) {
+	setupTestFile(t)
+	clearMetadata(t)
+	setTestMetadata(t, "Song", "Artist", "Album")
+	if err := Save(testFile, &Metadata{TrackName: "Song", Artist: "Artist", Album: "Album", Comment: "Ripped by X"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if err := Clear(testFile, []string{"artist", "comment"}); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+
+	readMeta, err := Read(testFile)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if readMeta.Artist != "" || readMeta.Comment != "" {
+		t.Errorf("expected artist and comment to be cleared, got %+v", readMeta)
+	}
+	if readMeta.TrackName != "Song" || readMeta.Album != "Album" {
+		t.Errorf("expected other fields to be kept, got %+v", readMeta)
+	}
+
+	if err := Clear(testFile, []string{"bogus"}); err == nil {
+		t.Error("expected error for unknown field")
+	}
+
+	clearMetadata(t)
+}
+
+func TestReadTechInfo(t *testing.T) {
+	setupTestFile(t)
+
+	info, err := ReadTechInfo(testFile)
+	if err != nil {
+		t.Fatalf("ReadTechInfo failed: %v", err)
+	}
+	if info.FileSize == 0 {
+		t.Error("expected file size")
+	}
+	if info.SampleRate == 0 || info.Bitrate == 0 || info.Duration == 0 {
+		t.Errorf("expected audio properties, got %+v", info)
+	}
+	t.Logf("tech info: %+v", info)
+}
+
+func TestReadTechInfoWithTag(t *testing.T) {
+	testFileWithMeta := "./../../test/test-w-metadata.mp3"
+	if _, err := os.Stat(testFileWithMeta); os.IsNotExist(err) {
+		t.Skip("test-w-metadata.mp3 not found")
+	}
+
+	info, err := ReadTechInfo(testFileWithMeta)
+	if err != nil {
+		t.Fatalf("ReadTechInfo failed: %v", err)
+	}
+	if info.TagVersion != 3 || info.TagSize == 0 {
+		t.Errorf("expected ID3v2.3 tag, got %+v", info)
+	}
+	if info.Duration == 0 {
+		t.Errorf("expected duration, got %+v", info)
+	}
+	t.Logf("tech info: %+v", info)
+}
+
+func TestReadPictures(t *testing.T) {
+	setupTestFile(t)
+	clearMetadata(t)
+
+	coverPath := "./../../test/test-cover.png"
+	if _, err := os.Stat(coverPath); os.IsNotExist(err) {
+		t.Skip("test-cover.png not found")
+	}
+	if err := Save(testFile, &Metadata{CoverPath: coverPath}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	pictures, err := ReadPictures(testFile)
+	if err != nil {
+		t.Fatalf("ReadPictures failed: %v", err)
+	}
+	if len(pictures) != 1 {
+		t.Fatalf("expected 1 picture, got %d", len(pictures))
+	}
+	p := pictures[0]
+	if p.Type != "Front cover" || p.MimeType != "image/png" || p.Size == 0 || len(p.SHA256) != 64 {
+		t.Errorf("unexpected picture %+v", p)
+	}
+
+	clearMetadata(t)
+}
+
+func TestSaveBackupPolicy(t *testing.T) {
+	defer func(saved BackupPolicy) { Backup = saved }(Backup)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.mp3")
+	data, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Skip("test.mp3 not found")
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	Backup = BackupPolicy{Mode: BackupOnce}
+	if err := Save(path, &Metadata{TrackName: "First"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := Save(path, &Metadata{TrackName: "Second"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	backup, err := os.ReadFile(path + ".bak")
+	if err != nil {
+		t.Fatalf("expected backup: %v", err)
+	}
+	if string(backup) != string(data) {
+		t.Error("once policy should keep the original file")
+	}
+
+	Backup = BackupPolicy{Mode: BackupAlways, Dir: filepath.Join(dir, "backups")}
+	if err := Save(path, &Metadata{TrackName: "Third"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	meta, err := Read(BackupPath(path))
+	if err != nil || meta.TrackName != "Second" {
+		t.Errorf("expected backup of previous state, got %+v, %v", meta, err)
+	}
+}
+
+func TestSaveDefaultVersion(t *testing.T) {
+	defer func(saved byte) { DefaultVersion = saved }(DefaultVersion)
+
+	path := filepath.Join(t.TempDir(), "empty.mp3")
+	if err := os.WriteFile(path, append([]byte{0xFF, 0xFB, 0x90, 0x00}, make([]byte, 412)...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	DefaultVersion = 3
+	if err := Save(path, &Metadata{Year: "1999"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	tag, err := id3v2.Open(path, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tag.Close()
+	if tag.Version() != 3 || tag.GetTextFrame("TYER").Text != "1999" {
+		t.Errorf("expected a v2.3 tag with TYER, got version %d", tag.Version())
+	}
+}
+
+func TestConvertVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "convert.mp3")
+	if err := os.WriteFile(path, append([]byte{0xFF, 0xFB, 0x90, 0x00}, make([]byte, 412)...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(path, &Metadata{TrackName: "Café", Year: "1969", Comment: "naïve"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if err := ConvertVersion(path, 3); err != nil {
+		t.Fatalf("ConvertVersion failed: %v", err)
+	}
+	tag, err := id3v2.Open(path, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	title := tag.GetTextFrame("TIT2")
+	if tag.Version() != 3 || tag.GetTextFrame("TYER").Text != "1969" || title.Text != "Café" || !title.Encoding.Equals(id3v2.EncodingUTF16) {
+		t.Errorf("unexpected v2.3 tag: version %d, title %+v", tag.Version(), title)
+	}
+	tag.Close()
+
+	if err := ConvertVersion(path, 4); err != nil {
+		t.Fatalf("ConvertVersion failed: %v", err)
+	}
+	meta, err := Read(path)
+	if err != nil || meta.TrackName != "Café" || meta.Year != "1969" || meta.Comment != "naïve" {
+		t.Errorf("unexpected metadata after converting back: %+v, %v", meta, err)
+	}
+
+	if err := ConvertVersion(path, 2); err == nil {
+		t.Error("expected an error for ID3v2.2")
 	}
+}
+
+func TestReadCover(t *testing.T) {
+	setupTestFile(t)
+	clearMetadata(t)
 
-	if meta.TrackName == "" && meta.Artist == "" && meta.Album == "" {
-		t.Log("Warning: file has no metadata, test may not be useful")
+	if _, _, err := ReadCover(testFile); err == nil {
+		t.Error("expected an error without a cover")
+	}
+
+	coverPath := "./../../test/test-cover.png"
+	want, err := os.ReadFile(coverPath)
+	if err != nil {
+		t.Skip("test-cover.png not found")
+	}
+	if err := Save(testFile, &Metadata{CoverPath: coverPath}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	data, mimeType, err := ReadCover(testFile)
+	if err != nil {
+		t.Fatalf("ReadCover failed: %v", err)
+	}
+	if string(data) != string(want) || PictureExtension(mimeType) != ".png" {
+		t.Errorf("unexpected cover: %d bytes, %s", len(data), mimeType)
+	}
+
+	clearMetadata(t)
+}
+
+func TestPlanSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.mp3")
+	if err := os.WriteFile(path, append([]byte{0xFF, 0xFB, 0x90, 0x00}, make([]byte, 412)...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(path, &Metadata{TrackName: "Old", Artist: "Same"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	changes, err := PlanSave(path, &Metadata{TrackName: "New", Artist: "Same", Year: "2001"})
+	if err != nil {
+		t.Fatalf("PlanSave failed: %v", err)
+	}
+	want := []Change{{"Track", "Old", "New"}, {"Year", "", "2001"}}
+	if len(changes) != len(want) || changes[0] != want[0] || changes[1] != want[1] {
+		t.Errorf("unexpected changes %+v", changes)
+	}
+
+	coverPath := "./../../test/test-cover.png"
+	if _, err := os.Stat(coverPath); os.IsNotExist(err) {
+		t.Skip("test-cover.png not found")
+	}
+	if err := Save(path, &Metadata{CoverPath: coverPath}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	changes, err = PlanSave(path, &Metadata{CoverPath: coverPath})
+	if err != nil {
+		t.Fatalf("PlanSave failed: %v", err)
+	}
+	if len(changes) != 1 || !strings.HasPrefix(changes[0].Old, "Front cover, image/png") || !strings.HasPrefix(changes[0].New, "test-cover.png, image/png") {
+		t.Errorf("expected the cover to be replaced, got %+v", changes)
+	}
+
+	if _, err := PlanSave(path, &Metadata{CoverPath: "missing.jpg"}); err == nil {
+		t.Error("expected an error for a missing cover file")
+	}
+}
+
+func TestSaveClearsEmptyFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clear.mp3")
+	if err := os.WriteFile(path, append([]byte{0xFF, 0xFB, 0x90, 0x00}, make([]byte, 412)...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(path, &Metadata{TrackName: "Song", Artist: "Artist", Album: "Album", Comment: "Note"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	update := &Metadata{TrackName: "Song", Artist: "", Album: "Album", Comment: ""}
+	changes, err := PlanSave(path, update)
+	if err != nil {
+		t.Fatalf("PlanSave failed: %v", err)
+	}
+	if len(changes) != 2 || changes[0] != (Change{"Artist", "Artist", ""}) || changes[1] != (Change{"Comment", "Note", ""}) {
+		t.Errorf("expected artist and comment removals, got %+v", changes)
+	}
+
+	if err := Save(path, update); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	meta, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.TrackName != "Song" || meta.Artist != "" || meta.Album != "Album" || meta.Comment != "" {
+		t.Errorf("unexpected metadata %+v", meta)
+	}
+
+	if err := Save(path, &Metadata{TrackName: "<keep>"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if meta, _ := Read(path); meta.TrackName != "<keep>" || meta.Album != "" {
+		t.Errorf("tag values should be stored as given, got %+v", meta)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	merged, mixed := Merge([]*Metadata{
+		{TrackName: "One", Artist: "Band", Album: "LP", Year: "1999"},
+		{TrackName: "Two", Artist: "Band", Album: "LP"},
+	})
+	if merged.TrackName != "" || merged.Year != "" || merged.Artist != "Band" || merged.Album != "LP" || merged.Disc != "" {
+		t.Errorf("unexpected merge %+v", merged)
+	}
+	if strings.Join(mixed, ",") != "title,year" {
+		t.Errorf("unexpected mixed fields %v", mixed)
+	}
+
+	updated := merged.Clone()
+	updated.Artist = ""
+	updated.Album = "EP"
+	if diff := merged.Diff(updated); diff != "Artist: Band → (removed)\nAlbum: LP → EP" {
+		t.Errorf("unexpected diff %q", diff)
+	}
+	if changes := merged.Changes(updated); len(changes) != 2 || changes[0] != (Change{"Artist", "Band", ""}) || changes[1] != (Change{"Album", "LP", "EP"}) {
+		t.Errorf("unexpected changes %+v", changes)
 	}
 }
@@ -0,0 +1,155 @@
+package metadata
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bogem/id3v2"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+type Candidate struct {
+	Encoding string
+	Text     string
+}
+
+type MojibakeField struct {
+	Field      string
+	Label      string
+	Original   string
+	Candidates []Candidate
+}
+
+var legacyEncodings = []struct {
+	name     string
+	encoding encoding.Encoding
+}{
+	{"CP1251", charmap.Windows1251},
+	{"CP1252", charmap.Windows1252},
+	{"Shift-JIS", japanese.ShiftJIS},
+}
+
+func latin1Bytes(s string) ([]byte, bool) {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xFF {
+			return nil, false
+		}
+		b = append(b, byte(r))
+	}
+	return b, true
+}
+
+func LooksMisdecoded(s string) bool {
+	if _, ok := latin1Bytes(s); !ok {
+		return false
+	}
+
+	letters, high, pairs := 0, 0, 0
+	previousHigh := false
+	for _, r := range s {
+		switch {
+		case r >= 0x80 && r <= 0x9F:
+			return true
+		case r >= 0xA0:
+			high++
+			letters++
+			if previousHigh {
+				pairs++
+			}
+		case unicode.IsLetter(r):
+			letters++
+		}
+		previousHigh = r >= 0xA0
+	}
+	return pairs >= 2 && high*2 >= letters
+}
+
+func plausibility(s string) int {
+	score := 0
+	for _, r := range s {
+		switch {
+		case r == utf8.RuneError, unicode.IsControl(r):
+			return -1
+		case unicode.IsLetter(r), unicode.IsDigit(r), unicode.IsSpace(r):
+			score += 2
+		case unicode.IsPunct(r):
+			score++
+		default:
+			score--
+		}
+	}
+	return score
+}
+
+func Redecode(s string) []Candidate {
+	raw, ok := latin1Bytes(s)
+	if !ok {
+		return nil
+	}
+
+	type scored struct {
+		Candidate
+		score int
+	}
+	var results []scored
+	for _, legacy := range legacyEncodings {
+		decoded, err := legacy.encoding.NewDecoder().Bytes(raw)
+		if err != nil {
+			continue
+		}
+		text := string(decoded)
+		if text == s {
+			continue
+		}
+		score := plausibility(text)
+		if score < 0 {
+			continue
+		}
+		results = append(results, scored{Candidate{legacy.name, text}, score})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].score > results[j].score
+	})
+
+	candidates := make([]Candidate, len(results))
+	for i, r := range results {
+		candidates[i] = r.Candidate
+	}
+	return candidates
+}
+
+func FindMojibake(filePath string) ([]MojibakeField, error) {
+	tag, err := openTag(filePath, id3v2.Options{Parse: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tag.Close()
+
+	var found []MojibakeField
+	for _, f := range fields {
+		text, encoding := f.read(tag)
+		if !encoding.Equals(id3v2.EncodingISO) || strings.TrimSpace(text) == "" {
+			continue
+		}
+		if !LooksMisdecoded(text) {
+			continue
+		}
+		candidates := Redecode(text)
+		if len(candidates) == 0 {
+			continue
+		}
+		found = append(found, MojibakeField{
+			Field:      f.name,
+			Label:      f.label,
+			Original:   text,
+			Candidates: candidates,
+		})
+	}
+	return found, nil
+}
@@ -0,0 +1,139 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/bogem/id3v2"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+func mojibake(t *testing.T, s string, enc encoding.Encoding) string {
+	raw, err := enc.NewEncoder().String(s)
+	if err != nil {
+		t.Fatalf("failed to encode %q: %v", s, err)
+	}
+	decoded, err := charmap.ISO8859_1.NewDecoder().String(raw)
+	if err != nil {
+		t.Fatalf("failed to decode as latin-1: %v", err)
+	}
+	return decoded
+}
+
+func TestLooksMisdecoded(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"Plain ASCII", false},
+		{"Café del Mar", false},
+		{"Né", false},
+		{"Aë", false},
+		{"Çé", false},
+		{"Zoë Björk", false},
+		{"Привет", false},
+		{mojibake(t, "Привет мир", charmap.Windows1251), true},
+		{mojibake(t, "Мир", charmap.Windows1251), true},
+		{mojibake(t, "日本語", japanese.ShiftJIS), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := LooksMisdecoded(tt.input); got != tt.expected {
+				t.Errorf("LooksMisdecoded(%q) = %v, expected %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRedecode(t *testing.T) {
+	tests := []struct {
+		original string
+		encoding encoding.Encoding
+		name     string
+	}{
+		{"Привет мир", charmap.Windows1251, "CP1251"},
+		{"日本語のタイトル", japanese.ShiftJIS, "Shift-JIS"},
+		{"“Quoted” – text", charmap.Windows1252, "CP1252"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates := Redecode(mojibake(t, tt.original, tt.encoding))
+			if len(candidates) == 0 {
+				t.Fatal("expected candidates")
+			}
+			found := false
+			for _, c := range candidates {
+				if c.Encoding == tt.name && c.Text == tt.original {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("expected %s candidate %q, got %+v", tt.name, tt.original, candidates)
+			}
+		})
+	}
+}
+
+func TestRedecodeRanksCyrillicFirst(t *testing.T) {
+	candidates := Redecode(mojibake(t, "Кино - Группа крови", charmap.Windows1251))
+	if len(candidates) == 0 || candidates[0].Encoding != "CP1251" {
+		t.Errorf("expected CP1251 to rank first, got %+v", candidates)
+	}
+}
+
+func TestRedecodeNonLatin1(t *testing.T) {
+	if candidates := Redecode("Привет"); candidates != nil {
+		t.Errorf("expected no candidates, got %+v", candidates)
+	}
+}
+
+func TestFindMojibakeAndFix(t *testing.T) {
+	setupTestFile(t)
+	clearMetadata(t)
+
+	garbled := mojibake(t, "Кино", charmap.Windows1251)
+	tag, err := id3v2.Open(testFile, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	tag.SetVersion(3)
+	tag.AddTextFrame(tag.CommonID("Artist"), id3v2.EncodingISO, garbled)
+	tag.AddTextFrame(tag.CommonID("Title"), id3v2.EncodingISO, "Plain title")
+	if err := tag.Save(); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+	tag.Close()
+
+	found, err := FindMojibake(testFile)
+	if err != nil {
+		t.Fatalf("FindMojibake failed: %v", err)
+	}
+	if len(found) != 1 || found[0].Field != "artist" {
+		t.Fatalf("expected mojibake in artist only, got %+v", found)
+	}
+	if found[0].Candidates[0].Text != "Кино" {
+		t.Errorf("expected 'Кино' as first candidate, got %+v", found[0].Candidates)
+	}
+
+	if err := Save(testFile, &Metadata{Artist: found[0].Candidates[0].Text}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	tag, err = id3v2.Open(testFile, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatalf("failed to reopen file: %v", err)
+	}
+	frame := tag.GetTextFrame(tag.CommonID("Artist"))
+	tag.Close()
+	if frame.Text != "Кино" {
+		t.Errorf("expected 'Кино', got '%s'", frame.Text)
+	}
+	if !frame.Encoding.Equals(id3v2.EncodingUTF16) {
+		t.Errorf("expected UTF-16 encoding for ID3v2.3, got %s", frame.Encoding)
+	}
+
+	clearMetadata(t)
+}
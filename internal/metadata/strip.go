@@ -0,0 +1,283 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bogem/id3v2"
+)
+
+type Profile struct {
+	Name   string
+	Keep   []string
+	Remove []string
+}
+
+var Profiles = DefaultProfiles()
+
+func DefaultProfiles() []Profile {
+	return []Profile{
+		{Name: "minimal", Keep: []string{"title", "artist", "album", "track", "cover"}},
+		{Name: "clean", Remove: []string{"PRIV", "TSSE", "TENC", "COMM:iTunNORM", "COMM:iTunSMPB", "COMM:iTunPGAP", "COMM:ID3v1 Comment"}},
+	}
+}
+
+func LookupProfile(name string) (Profile, bool) {
+	for _, p := range Profiles {
+		if strings.EqualFold(p.Name, name) {
+			return p, true
+		}
+	}
+	return Profile{}, false
+}
+
+func ProfileNames() []string {
+	names := make([]string, len(Profiles))
+	for i, p := range Profiles {
+		names[i] = p.Name
+	}
+	return names
+}
+
+func ValidateRule(rule string) error {
+	id, _, _ := strings.Cut(rule, ":")
+	if rule == "cover" || IsField(rule) {
+		return nil
+	}
+	if len(id) != 4 || strings.ToUpper(id) != id {
+		return fmt.Errorf("%q is neither a field (%s, cover) nor a frame ID such as PRIV or COMM:description", rule, strings.Join(Fields(), ", "))
+	}
+	for _, r := range id {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return fmt.Errorf("invalid frame ID %q", id)
+		}
+	}
+	return nil
+}
+
+func ruleMatches(tag *id3v2.Tag, rule, id string, frame id3v2.Framer) bool {
+	if rule == "cover" {
+		return id == tag.CommonID("Attached picture")
+	}
+	if f, ok := lookupField(rule); ok {
+		return id == f.frameID(tag)
+	}
+	ruleID, desc, hasDesc := strings.Cut(rule, ":")
+	if ruleID != id {
+		return false
+	}
+	return !hasDesc || strings.EqualFold(desc, frameDescription(frame))
+}
+
+func (p Profile) drops(tag *id3v2.Tag, id string, frame id3v2.Framer) bool {
+	matches := func(rules []string) bool {
+		for _, rule := range rules {
+			if ruleMatches(tag, rule, id, frame) {
+				return true
+			}
+		}
+		return false
+	}
+	return len(p.Keep) > 0 && !matches(p.Keep) || matches(p.Remove)
+}
+
+func frameDescription(frame id3v2.Framer) string {
+	switch f := frame.(type) {
+	case id3v2.CommentFrame:
+		return f.Description
+	case id3v2.UserDefinedTextFrame:
+		return f.Description
+	case id3v2.PictureFrame:
+		return f.Description
+	case id3v2.UFIDFrame:
+		return f.OwnerIdentifier
+	case id3v2.UnknownFrame:
+		owner, _, _ := bytes.Cut(f.Body, []byte{0})
+		return string(owner)
+	}
+	return ""
+}
+
+func frameSummary(id string, frame id3v2.Framer) string {
+	label := id
+	if desc := frameDescription(frame); desc != "" {
+		label += " (" + desc + ")"
+	}
+
+	var value string
+	switch f := frame.(type) {
+	case id3v2.TextFrame:
+		value = f.Text
+	case id3v2.CommentFrame:
+		value = f.Text
+	case id3v2.UserDefinedTextFrame:
+		value = f.Value
+	case id3v2.PictureFrame:
+		value = fmt.Sprintf("%s, %s", f.MimeType, formatSize(int64(len(f.Picture))))
+	default:
+		value = formatSize(int64(frame.Size()))
+	}
+	if runes := []rune(value); len(runes) > 60 {
+		value = string(runes[:57]) + "..."
+	}
+	return label + ": " + value
+}
+
+func sortedFrames(tag *id3v2.Tag) ([]string, map[string][]id3v2.Framer) {
+	frames := tag.AllFrames()
+	ids := make([]string, 0, len(frames))
+	for id := range frames {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, frames
+}
+
+func PlanProfile(filePath string, p Profile) ([]string, error) {
+	tag, err := openTag(filePath, id3v2.Options{Parse: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tag.Close()
+
+	var dropped []string
+	ids, frames := sortedFrames(tag)
+	for _, id := range ids {
+		for _, frame := range frames[id] {
+			if p.drops(tag, id, frame) {
+				dropped = append(dropped, frameSummary(id, frame))
+			}
+		}
+	}
+	return dropped, nil
+}
+
+func ApplyProfile(filePath string, p Profile) (int, error) {
+	tag, err := openTag(filePath, id3v2.Options{Parse: true})
+	if err != nil {
+		return 0, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer tag.Close()
+
+	dropped := 0
+	ids, frames := sortedFrames(tag)
+	for _, id := range ids {
+		var kept []id3v2.Framer
+		for _, frame := range frames[id] {
+			if p.drops(tag, id, frame) {
+				dropped++
+			} else {
+				kept = append(kept, frame)
+			}
+		}
+		if len(kept) == len(frames[id]) {
+			continue
+		}
+		tag.DeleteFrames(id)
+		for _, frame := range kept {
+			tag.AddFrame(id, frame)
+		}
+	}
+	if dropped == 0 {
+		return 0, nil
+	}
+
+	if err := backup(filePath); err != nil {
+		return 0, fmt.Errorf("failed to back up file: %w", err)
+	}
+	if err := tag.Save(); err != nil {
+		return 0, fmt.Errorf("failed to save metadata: %w", err)
+	}
+	return dropped, nil
+}
+
+type tagBlock struct {
+	name   string
+	offset int64
+	size   int64
+}
+
+func findTags(data []byte) []tagBlock {
+	var blocks []tagBlock
+	if len(data) >= 10 && string(data[:3]) == "ID3" {
+		size, version := id3v2Size(data[:10])
+		blocks = append(blocks, tagBlock{fmt.Sprintf("ID3v2.%d", version), 0, min(size, int64(len(data)))})
+	}
+
+	end := int64(len(data))
+	if end >= 128 && string(data[end-128:end-125]) == "TAG" {
+		blocks = append(blocks, tagBlock{"ID3v1", end - 128, 128})
+		end -= 128
+	}
+
+	if end >= 32 && string(data[end-32:end-24]) == "APETAGEX" {
+		footer := data[end-32 : end]
+		size := int64(binary.LittleEndian.Uint32(footer[12:16]))
+		if binary.LittleEndian.Uint32(footer[20:24])&(1<<31) != 0 {
+			size += 32
+		}
+		start := max(end-size, 0)
+		if len(blocks) > 0 && blocks[0].offset == 0 {
+			start = max(start, blocks[0].size)
+		}
+		blocks = append(blocks, tagBlock{"APE", start, end - start})
+	}
+	return blocks
+}
+
+func PlanStrip(filePath string) ([]string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	for _, block := range findTags(data) {
+		line := fmt.Sprintf("%s tag, %s", block.name, formatSize(block.size))
+		if strings.HasPrefix(block.name, "ID3v2") {
+			if tag, err := id3v2.ParseReader(bytes.NewReader(data), id3v2.Options{Parse: true}); err == nil {
+				ids, _ := sortedFrames(tag)
+				line += ": " + strings.Join(ids, ", ")
+			} else {
+				line += " (unreadable)"
+			}
+		}
+		tags = append(tags, line)
+	}
+	return tags, nil
+}
+
+func Strip(filePath string) (bool, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return false, err
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return false, err
+	}
+	blocks := findTags(data)
+	if len(blocks) == 0 {
+		return false, nil
+	}
+
+	if err := backup(filePath); err != nil {
+		return false, fmt.Errorf("failed to back up file: %w", err)
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].offset < blocks[j].offset })
+	var audio bytes.Buffer
+	var pos int64
+	for _, block := range blocks {
+		audio.Write(data[pos:block.offset])
+		pos = block.offset + block.size
+	}
+	audio.Write(data[pos:])
+	if err := os.WriteFile(filePath, audio.Bytes(), info.Mode()); err != nil {
+		return false, fmt.Errorf("failed to strip tags: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,132 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bogem/id3v2"
+)
+
+var audioFrame = append([]byte{0xFF, 0xFB, 0x90, 0x00}, make([]byte, 412)...)
+
+func apeTag() []byte {
+	item := append([]byte{5, 0, 0, 0, 0, 0, 0, 0}, []byte("Title\x00Hello")...)
+	footer := make([]byte, 32)
+	copy(footer, "APETAGEX")
+	binary.LittleEndian.PutUint32(footer[8:], 2000)
+	binary.LittleEndian.PutUint32(footer[12:], uint32(len(item)+32))
+	binary.LittleEndian.PutUint32(footer[16:], 1)
+	return append(item, footer...)
+}
+
+func id3v1Tag() []byte {
+	tag := make([]byte, 128)
+	copy(tag, "TAGOld title")
+	return tag
+}
+
+func TestStrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tagged.mp3")
+	if err := os.WriteFile(path, audioFrame, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(path, &Metadata{TrackName: "Title", Artist: "Artist"}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	data = append(append(data, apeTag()...), id3v1Tag()...)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := PlanStrip(path)
+	if err != nil {
+		t.Fatalf("PlanStrip failed: %v", err)
+	}
+	if len(tags) != 3 || !strings.HasPrefix(tags[0], "ID3v2.4 tag") || !strings.Contains(tags[0], "TIT2, TPE1") ||
+		!strings.HasPrefix(tags[1], "ID3v1 tag") || !strings.HasPrefix(tags[2], "APE tag") {
+		t.Errorf("unexpected tags %q", tags)
+	}
+
+	stripped, err := Strip(path)
+	if err != nil || !stripped {
+		t.Fatalf("Strip = %v, %v", stripped, err)
+	}
+	data, _ = os.ReadFile(path)
+	if !bytes.Equal(data, audioFrame) {
+		t.Errorf("expected only the audio to remain, got %d bytes", len(data))
+	}
+
+	if stripped, err := Strip(path); err != nil || stripped {
+		t.Errorf("stripping an untagged file should do nothing, got %v, %v", stripped, err)
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profile.mp3")
+	if err := os.WriteFile(path, audioFrame, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(path, &Metadata{TrackName: "Title", Artist: "Artist", Year: "1999", Comment: "Nice"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tag, err := id3v2.Open(path, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag.AddTextFrame("TSSE", id3v2.EncodingUTF8, "LAME 3.100")
+	tag.AddFrame("PRIV", id3v2.UnknownFrame{Body: []byte("WM/MediaClassPrimaryID\x00\x01\x02")})
+	tag.AddCommentFrame(id3v2.CommentFrame{Encoding: id3v2.EncodingUTF8, Language: "eng", Description: "iTunNORM", Text: "000001"})
+	if err := tag.Save(); err != nil {
+		t.Fatal(err)
+	}
+	tag.Close()
+
+	clean, _ := LookupProfile("clean")
+	dropped, err := PlanProfile(path, clean)
+	if err != nil {
+		t.Fatalf("PlanProfile failed: %v", err)
+	}
+	want := []string{"COMM (iTunNORM): 000001", "PRIV (WM/MediaClassPrimaryID): 25 B", "TSSE: LAME 3.100"}
+	if strings.Join(dropped, "|") != strings.Join(want, "|") {
+		t.Errorf("unexpected plan %q", dropped)
+	}
+
+	if n, err := ApplyProfile(path, clean); err != nil || n != 3 {
+		t.Fatalf("ApplyProfile = %d, %v", n, err)
+	}
+	meta, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.TrackName != "Title" || meta.Comment != "Nice" || meta.Year != "1999" {
+		t.Errorf("clean should keep regular tags, got %+v", meta)
+	}
+
+	minimal := Profile{Name: "minimal", Keep: []string{"title", "artist"}}
+	if n, err := ApplyProfile(path, minimal); err != nil || n != 2 {
+		t.Fatalf("ApplyProfile = %d, %v", n, err)
+	}
+	meta, _ = Read(path)
+	if meta.TrackName != "Title" || meta.Artist != "Artist" || meta.Comment != "" || meta.Year != "" {
+		t.Errorf("minimal should keep only title and artist, got %+v", meta)
+	}
+}
+
+func TestValidateRule(t *testing.T) {
+	for _, rule := range []string{"title", "cover", "PRIV", "COMM:iTunNORM", "TXXX:replaygain_track_gain"} {
+		if err := ValidateRule(rule); err != nil {
+			t.Errorf("ValidateRule(%q) failed: %v", rule, err)
+		}
+	}
+	for _, rule := range []string{"priv", "TOOLONG", "T$SE", ""} {
+		if err := ValidateRule(rule); err == nil {
+			t.Errorf("ValidateRule(%q) should fail", rule)
+		}
+	}
+}
@@ -1,33 +1,480 @@
 package modals
 
 import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 
+	"id3v2-tui/internal/keymap"
 	"id3v2-tui/internal/theme"
 )
 
+var Keys = keymap.Default()
+
+func modalAction(app *tview.Application, form *tview.Form, event *tcell.EventKey) string {
+	if form != nil {
+		if index, _ := form.GetFocusedItemIndex(); index >= 0 {
+			if dropDown, ok := form.GetFormItem(index).(*tview.DropDown); ok && dropDown.IsOpen() {
+				return ""
+			}
+		}
+	}
+	_, typing := app.GetFocus().(*tview.InputField)
+	action, _ := Keys.Lookup(keymap.ContextModal, event, typing)
+	return action
+}
+
+func closeOnCancel(app *tview.Application, form *tview.Form, root tview.Primitive) func(event *tcell.EventKey) *tcell.EventKey {
+	return func(event *tcell.EventKey) *tcell.EventKey {
+		if modalAction(app, form, event) == "cancel" {
+			app.SetRoot(root, false)
+			return nil
+		}
+		return event
+	}
+}
+
 func ShowError(app *tview.Application, root tview.Primitive, msg string) {
 	modal := tview.NewModal()
 	modal.SetText(msg)
+	theme.StyleModal(modal)
 	modal.SetTextColor(theme.Error)
 	modal.AddButtons([]string{"OK"})
-	modal.SetButtonBackgroundColor(theme.Secondary)
-	modal.SetButtonTextColor(theme.Text)
 	modal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
 		app.SetRoot(root, false)
 	})
+	modal.SetInputCapture(closeOnCancel(app, nil, root))
 	app.SetRoot(modal, false)
 }
 
 func ShowMessage(app *tview.Application, root tview.Primitive, msg string) {
 	modal := tview.NewModal()
 	modal.SetText(msg)
-	modal.SetTextColor(theme.Text)
+	theme.StyleModal(modal)
 	modal.AddButtons([]string{"OK"})
-	modal.SetButtonBackgroundColor(theme.Secondary)
-	modal.SetButtonTextColor(theme.Text)
 	modal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
 		app.SetRoot(root, false)
 	})
+	modal.SetInputCapture(closeOnCancel(app, nil, root))
+	app.SetRoot(modal, false)
+}
+
+func center(p tview.Primitive, width, height int) tview.Primitive {
+	return tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 1, true).
+			AddItem(nil, 0, 1, false), width, 1, true).
+		AddItem(nil, 0, 1, false)
+}
+
+func ShowInput(app *tview.Application, root tview.Primitive, title, label, initial string, onSubmit func(text string)) {
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle(title)
+	theme.StyleForm(form)
+	form.SetButtonsAlign(tview.AlignCenter)
+
+	form.AddInputField(label, initial, 60, nil, nil)
+	form.AddButton("OK", func() {
+		text := form.GetFormItem(0).(*tview.InputField).GetText()
+		app.SetRoot(root, false)
+		onSubmit(text)
+	})
+	form.AddButton("Cancel", func() {
+		app.SetRoot(root, false)
+	})
+	form.SetInputCapture(closeOnCancel(app, form, root))
+
+	app.SetRoot(center(form, 80, 7), true)
+	app.SetFocus(form)
+}
+
+func ShowPreview(app *tview.Application, root tview.Primitive, title, text string, onApply func()) {
+	view := tview.NewTextView().
+		SetText(text).
+		SetScrollable(true)
+	view.SetBorder(true).SetTitle(title)
+	theme.StyleTextView(view)
+
+	buttons := tview.NewForm()
+	theme.StyleForm(buttons)
+	buttons.SetButtonsAlign(tview.AlignCenter)
+	buttons.AddButton("Apply", func() {
+		app.SetRoot(root, false)
+		onApply()
+	})
+	buttons.AddButton("Cancel", func() {
+		app.SetRoot(root, false)
+	})
+	buttons.SetInputCapture(closeOnCancel(app, buttons, root))
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(view, 0, 1, false).
+		AddItem(buttons, 3, 0, true)
+	layout.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn:
+			view.InputHandler()(event, func(p tview.Primitive) {})
+			return nil
+		}
+		return event
+	})
+
+	app.SetRoot(center(layout, 100, 24), true)
+	app.SetFocus(buttons)
+}
+
+type FindReplaceRequest struct {
+	Pattern     string
+	Replacement string
+	Fields      string
+	Recursive   bool
+}
+
+func ShowFindReplace(app *tview.Application, root tview.Primitive, recursive bool, preview func(req FindReplaceRequest) string, onReview func(req FindReplaceRequest)) {
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle("Find and replace")
+	theme.StyleForm(form)
+	form.SetButtonsAlign(tview.AlignCenter)
+
+	previewView := tview.NewTextView()
+	previewView.SetBorder(true).SetTitle("Preview")
+	theme.StyleTextView(previewView)
+
+	scopes := []string{"Selected files", "Current directory (recursive)"}
+	scopeIndex := 0
+	if recursive {
+		scopeIndex = 1
+	}
+
+	request := func() FindReplaceRequest {
+		scope, _ := form.GetFormItemByLabel("Scope").(*tview.DropDown).GetCurrentOption()
+		return FindReplaceRequest{
+			Pattern:     form.GetFormItemByLabel("Pattern").(*tview.InputField).GetText(),
+			Replacement: form.GetFormItemByLabel("Replacement").(*tview.InputField).GetText(),
+			Fields:      form.GetFormItemByLabel("Fields").(*tview.InputField).GetText(),
+			Recursive:   scope == 1,
+		}
+	}
+	update := func(string) {
+		if form.GetFormItemCount() < 4 {
+			return
+		}
+		previewView.SetText(preview(request()))
+		previewView.ScrollToBeginning()
+	}
+
+	form.AddInputField("Pattern", "", 50, nil, update)
+	form.AddInputField("Replacement", "", 50, nil, update)
+	form.AddInputField("Fields", "", 50, nil, update)
+	form.AddDropDown("Scope", scopes, scopeIndex, func(string, int) { update("") })
+	form.AddButton("Review", func() {
+		req := request()
+		app.SetRoot(root, false)
+		onReview(req)
+	})
+	form.AddButton("Cancel", func() {
+		app.SetRoot(root, false)
+	})
+	form.SetInputCapture(closeOnCancel(app, form, root))
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(form, 13, 0, true).
+		AddItem(previewView, 0, 1, false)
+
+	update("")
+	app.SetRoot(center(layout, 100, 30), true)
+	app.SetFocus(form)
+}
+
+type ChecklistItem struct {
+	Label   string
+	Detail  string
+	Checked bool
+}
+
+func ShowChecklist(app *tview.Application, root tview.Primitive, title string, items []ChecklistItem, onApply func(items []ChecklistItem)) {
+	list := tview.NewList()
+	list.SetBorder(true).SetTitle(title + " (Space: accept/skip)")
+	theme.StyleList(list)
+
+	label := func(item ChecklistItem) string {
+		if item.Checked {
+			return "[x] " + item.Label
+		}
+		return "[ ] " + item.Label
+	}
+	toggle := func(index int) {
+		items[index].Checked = !items[index].Checked
+		list.SetItemText(index, label(items[index]), items[index].Detail)
+	}
+
+	for i, item := range items {
+		index := i
+		list.AddItem(label(item), item.Detail, 0, func() { toggle(index) })
+	}
+
+	buttons := tview.NewForm()
+	theme.StyleForm(buttons)
+	buttons.SetButtonsAlign(tview.AlignCenter)
+	buttons.AddButton("Apply", func() {
+		app.SetRoot(root, false)
+		onApply(items)
+	})
+	buttons.AddButton("Cancel", func() {
+		app.SetRoot(root, false)
+	})
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(list, 0, 1, true).
+		AddItem(buttons, 3, 0, false)
+	layout.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch modalAction(app, nil, event) {
+		case "toggle":
+			if list.HasFocus() {
+				toggle(list.GetCurrentItem())
+				return nil
+			}
+		case "focus_next":
+			if list.HasFocus() {
+				app.SetFocus(buttons)
+				return nil
+			}
+		case "focus_prev":
+			if !list.HasFocus() {
+				app.SetFocus(list)
+				return nil
+			}
+		case "cancel":
+			app.SetRoot(root, false)
+			return nil
+		}
+		return event
+	})
+
+	app.SetRoot(center(layout, 100, 24), true)
+	app.SetFocus(list)
+}
+
+func ShowTextActions(app *tview.Application, root tview.Primitive, actions, scopes []string, onApply func(action int, fields string, scope int)) {
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle("Text actions")
+	theme.StyleForm(form)
+	form.SetButtonsAlign(tview.AlignCenter)
+
+	form.AddDropDown("Action", actions, 0, nil)
+	form.AddInputField("Fields", "", 40, nil, nil)
+	form.AddDropDown("Apply to", scopes, 0, nil)
+	form.AddButton("Apply", func() {
+		action, _ := form.GetFormItemByLabel("Action").(*tview.DropDown).GetCurrentOption()
+		fields := form.GetFormItemByLabel("Fields").(*tview.InputField).GetText()
+		scope, _ := form.GetFormItemByLabel("Apply to").(*tview.DropDown).GetCurrentOption()
+		app.SetRoot(root, false)
+		onApply(action, fields, scope)
+	})
+	form.AddButton("Cancel", func() {
+		app.SetRoot(root, false)
+	})
+	form.SetInputCapture(closeOnCancel(app, form, root))
+
+	app.SetRoot(center(form, 70, 11), true)
+	app.SetFocus(form)
+}
+
+type Choice struct {
+	Label    string
+	Options  []string
+	Selected int
+}
+
+func ShowChoices(app *tview.Application, root tview.Primitive, title string, choices []Choice, onApply func(selected []int)) {
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle(title)
+	theme.StyleForm(form)
+	form.SetButtonsAlign(tview.AlignCenter)
+
+	for _, choice := range choices {
+		form.AddDropDown(choice.Label, choice.Options, choice.Selected, nil)
+	}
+	form.AddButton("Apply", func() {
+		selected := make([]int, len(choices))
+		for i := range choices {
+			selected[i], _ = form.GetFormItem(i).(*tview.DropDown).GetCurrentOption()
+		}
+		app.SetRoot(root, false)
+		onApply(selected)
+	})
+	form.AddButton("Cancel", func() {
+		app.SetRoot(root, false)
+	})
+	form.SetInputCapture(closeOnCancel(app, form, root))
+
+	app.SetRoot(center(form, 90, len(choices)*2+5), true)
+	app.SetFocus(form)
+}
+
+func ShowHelp(app *tview.Application, root tview.Primitive, text string) {
+	view := tview.NewTextView().
+		SetText(text).
+		SetScrollable(true)
+	view.SetBorder(true).SetTitle("Keybindings (Esc to close)")
+	theme.StyleTextView(view)
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if modalAction(app, nil, event) == "cancel" || event.Key() == tcell.KeyEnter || event.Rune() == '?' {
+			app.SetRoot(root, false)
+			return nil
+		}
+		return event
+	})
+
+	app.SetRoot(center(view, 70, 30), true)
+	app.SetFocus(view)
+}
+
+type PaletteItem struct {
+	Name        string
+	Description string
+	Key         string
+}
+
+func ShowPalette(app *tview.Application, root tview.Primitive, search func(query string) []PaletteItem, onSelect func(name string)) {
+	input := tview.NewInputField().SetLabel("> ")
+	input.SetLabelColor(theme.Primary)
+	input.SetFieldStyle(tcell.StyleDefault.Foreground(theme.Text).Background(theme.Background))
+
+	list := tview.NewList().ShowSecondaryText(false)
+	theme.StyleList(list)
+
+	var items []PaletteItem
+	update := func(query string) {
+		items = search(query)
+		list.Clear()
+		for _, item := range items {
+			list.AddItem(fmt.Sprintf("%-40s [%s]%s", tview.Escape(item.Description), theme.HexTextDim, tview.Escape(item.Key)), "", 0, nil)
+		}
+	}
+	input.SetChangedFunc(update)
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn:
+			list.InputHandler()(event, func(p tview.Primitive) {})
+			return nil
+		case tcell.KeyEnter:
+			if len(items) > 0 {
+				name := items[list.GetCurrentItem()].Name
+				app.SetRoot(root, false)
+				onSelect(name)
+			}
+			return nil
+		}
+		if modalAction(app, nil, event) == "cancel" {
+			app.SetRoot(root, false)
+			return nil
+		}
+		return event
+	})
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(input, 1, 0, true).
+		AddItem(list, 0, 1, false)
+	layout.SetBorder(true).SetTitle("Command palette")
+	theme.StyleBox(layout.Box)
+
+	update("")
+	app.SetRoot(center(layout, 70, 20), true)
+	app.SetFocus(input)
+}
+
+func ShowConfirm(app *tview.Application, root tview.Primitive, text, label string, onConfirm func()) {
+	modal := tview.NewModal()
+	modal.SetText(text)
+	theme.StyleModal(modal)
+	modal.AddButtons([]string{label, "Cancel"})
+	modal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		app.SetRoot(root, false)
+		if buttonLabel == label {
+			onConfirm()
+		}
+	})
+	modal.SetInputCapture(closeOnCancel(app, nil, root))
+	app.SetRoot(modal, false)
+}
+
+func ShowUnsaved(app *tview.Application, root tview.Primitive, text string, onSave, onDiscard func()) {
+	modal := tview.NewModal()
+	modal.SetText(text)
+	theme.StyleModal(modal)
+	modal.AddButtons([]string{"Save", "Discard", "Cancel"})
+	modal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		app.SetRoot(root, false)
+		switch buttonLabel {
+		case "Save":
+			onSave()
+		case "Discard":
+			onDiscard()
+		}
+	})
+	modal.SetInputCapture(closeOnCancel(app, nil, root))
 	app.SetRoot(modal, false)
 }
+
+type DiffRow struct {
+	Label string
+	Old   string
+	New   string
+}
+
+func ShowDiff(app *tview.Application, root tview.Primitive, title string, rows []DiffRow, onApply func()) {
+	table := tview.NewTable().SetFixed(1, 0).SetSelectable(true, false)
+	table.SetBorder(true).SetTitle(title)
+	theme.StyleBox(table.Box)
+	table.SetSelectedStyle(tcell.StyleDefault.Foreground(theme.Background).Background(theme.TextDim))
+
+	for column, header := range []string{"Field", "Old", "New"} {
+		table.SetCell(0, column, tview.NewTableCell(header).
+			SetTextColor(theme.Primary).
+			SetSelectable(false).
+			SetExpansion(column))
+	}
+	for i, row := range rows {
+		old, updated := row.Old, row.New
+		if old == "" {
+			old = "(empty)"
+		}
+		if updated == "" {
+			updated = "(removed)"
+		}
+		table.SetCell(i+1, 0, tview.NewTableCell(tview.Escape(row.Label)).SetTextColor(theme.TextDim))
+		table.SetCell(i+1, 1, tview.NewTableCell(tview.Escape(old)).SetTextColor(theme.Error).SetExpansion(1))
+		table.SetCell(i+1, 2, tview.NewTableCell(tview.Escape(updated)).SetTextColor(theme.Text).SetExpansion(1))
+	}
+
+	buttons := tview.NewForm()
+	theme.StyleForm(buttons)
+	buttons.SetButtonsAlign(tview.AlignCenter)
+	buttons.AddButton("Apply", func() {
+		app.SetRoot(root, false)
+		onApply()
+	})
+	buttons.AddButton("Cancel", func() {
+		app.SetRoot(root, false)
+	})
+	buttons.SetInputCapture(closeOnCancel(app, buttons, root))
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, false).
+		AddItem(buttons, 3, 0, true)
+	layout.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn:
+			table.InputHandler()(event, func(p tview.Primitive) {})
+			return nil
+		}
+		return event
+	})
+
+	app.SetRoot(center(layout, 100, min(len(rows)+8, 24)), true)
+	app.SetFocus(buttons)
+}
@@ -0,0 +1,244 @@
+package rename
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"id3v2-tui/internal/metadata"
+)
+
+const DefaultTemplate = "%albumartist%/%year% - %album%/%track:02% %title%.mp3"
+
+type Move struct {
+	From string
+	To   string
+}
+
+type Plan struct {
+	Base       string
+	Moves      []Move
+	Created    []string
+	Collisions []string
+}
+
+var illegalChars = strings.NewReplacer(
+	"/", "_", "\\", "_", ":", "_", "*", "_", "?", "_",
+	"\"", "_", "<", "_", ">", "_", "|", "_",
+)
+
+func Sanitize(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+	s = illegalChars.Replace(s)
+	return strings.TrimRight(strings.TrimSpace(s), ". ")
+}
+
+func Format(template string, meta *metadata.Metadata) (string, error) {
+	var b strings.Builder
+	rest := template
+	for {
+		start := strings.IndexByte(rest, '%')
+		if start < 0 {
+			b.WriteString(rest)
+			break
+		}
+		b.WriteString(rest[:start])
+		rest = rest[start+1:]
+
+		end := strings.IndexByte(rest, '%')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated placeholder in template %q", template)
+		}
+		placeholder := rest[:end]
+		rest = rest[end+1:]
+
+		if placeholder == "" {
+			b.WriteByte('%')
+			continue
+		}
+		value, err := expand(placeholder, meta)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value)
+	}
+	return b.String(), nil
+}
+
+func expand(placeholder string, meta *metadata.Metadata) (string, error) {
+	name, width, hasWidth := strings.Cut(placeholder, ":")
+	value, ok := meta.Get(name)
+	if !ok {
+		return "", fmt.Errorf("unknown field %q in template", name)
+	}
+
+	if name == "track" || name == "disc" {
+		value, _, _ = strings.Cut(value, "/")
+	}
+
+	if hasWidth {
+		n, err := strconv.Atoi(width)
+		if err != nil || n < 0 {
+			return "", fmt.Errorf("invalid width %q for field %q", width, name)
+		}
+		if num, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			value = fmt.Sprintf("%0*d", n, num)
+		}
+	}
+
+	return Sanitize(value), nil
+}
+
+func Target(base, template, source string, meta *metadata.Metadata) (string, error) {
+	formatted, err := Format(template, meta)
+	if err != nil {
+		return "", err
+	}
+
+	parts := strings.Split(formatted, "/")
+	for i, part := range parts {
+		part = strings.TrimRight(strings.TrimSpace(part), ". ")
+		if part == "" {
+			return "", fmt.Errorf("%s: template produced an empty path component", filepath.Base(source))
+		}
+		parts[i] = part
+	}
+
+	target := filepath.Join(append([]string{base}, parts...)...)
+	if filepath.Ext(target) == "" {
+		target += filepath.Ext(source)
+	}
+	return target, nil
+}
+
+func NewPlan(base, template string, sources []string, metas []*metadata.Metadata) (*Plan, error) {
+	if len(sources) != len(metas) {
+		return nil, errors.New("sources and metadata count mismatch")
+	}
+
+	plan := &Plan{Base: base}
+	claimed := make(map[string]string)
+	for i, source := range sources {
+		target, err := Target(base, template, source, metas[i])
+		if err != nil {
+			return nil, err
+		}
+		if target == source {
+			continue
+		}
+
+		key := strings.ToLower(target)
+		if other, ok := claimed[key]; ok {
+			plan.Collisions = append(plan.Collisions, fmt.Sprintf("%s and %s both map to %s",
+				plan.rel(other), plan.rel(source), plan.rel(target)))
+			continue
+		}
+		claimed[key] = source
+
+		if exists(target, source) {
+			plan.Collisions = append(plan.Collisions, fmt.Sprintf("%s already exists", plan.rel(target)))
+			continue
+		}
+
+		plan.Moves = append(plan.Moves, Move{From: source, To: target})
+	}
+
+	return plan, nil
+}
+
+func (p *Plan) rel(path string) string {
+	if rel, err := filepath.Rel(p.Base, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+func (p *Plan) Preview() string {
+	if len(p.Moves) == 0 {
+		return "No files need renaming"
+	}
+
+	lines := make([]string, len(p.Moves))
+	for i, m := range p.Moves {
+		lines[i] = fmt.Sprintf("%s → %s", p.rel(m.From), p.rel(m.To))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func exists(target, source string) bool {
+	targetInfo, err := os.Stat(target)
+	if err != nil {
+		return false
+	}
+	sourceInfo, err := os.Stat(source)
+	return err != nil || !os.SameFile(targetInfo, sourceInfo)
+}
+
+func Apply(moves []Move) ([]Move, []string, error) {
+	var applied []Move
+	var created []string
+	for _, m := range moves {
+		if exists(m.To, m.From) {
+			return applied, created, fmt.Errorf("refusing to overwrite %s", m.To)
+		}
+		dirs := missingDirs(filepath.Dir(m.To))
+		if err := os.MkdirAll(filepath.Dir(m.To), 0o755); err != nil {
+			return applied, created, fmt.Errorf("failed to create directory: %w", err)
+		}
+		created = append(created, dirs...)
+		if err := os.Rename(m.From, m.To); err != nil {
+			return applied, created, fmt.Errorf("failed to rename %s: %w", filepath.Base(m.From), err)
+		}
+		applied = append(applied, m)
+	}
+	return applied, created, nil
+}
+
+func missingDirs(dir string) []string {
+	var dirs []string
+	for {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			return dirs
+		}
+		dirs = append(dirs, dir)
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dirs
+		}
+		dir = parent
+	}
+}
+
+func Undo(applied []Move, created []string) error {
+	for i := len(applied) - 1; i >= 0; i-- {
+		m := applied[i]
+		if exists(m.From, m.To) {
+			return fmt.Errorf("refusing to overwrite %s", m.From)
+		}
+		if err := os.MkdirAll(filepath.Dir(m.From), 0o755); err != nil {
+			return fmt.Errorf("failed to recreate directory: %w", err)
+		}
+		if err := os.Rename(m.To, m.From); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", filepath.Base(m.From), err)
+		}
+	}
+	removeEmpty(created)
+	return nil
+}
+
+func removeEmpty(dirs []string) {
+	sorted := append([]string(nil), dirs...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	for _, dir := range sorted {
+		os.Remove(dir)
+	}
+}
@@ -0,0 +1,267 @@
+package rename
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"id3v2-tui/internal/metadata"
+)
+
+func testMeta() *metadata.Metadata {
+	return &metadata.Metadata{
+		TrackName:   "Come Together",
+		Artist:      "The Beatles",
+		Album:       "Abbey Road",
+		AlbumArtist: "The Beatles",
+		Year:        "1969",
+		Track:       "1/17",
+	}
+}
+
+func TestFormat(t *testing.T) {
+	got, err := Format(DefaultTemplate, testMeta())
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	expected := "The Beatles/1969 - Abbey Road/01 Come Together.mp3"
+	if got != expected {
+		t.Errorf("expected '%s', got '%s'", expected, got)
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	tests := []string{
+		"%unknown%.mp3",
+		"%title.mp3",
+		"%track:x%.mp3",
+	}
+
+	for _, template := range tests {
+		t.Run(template, func(t *testing.T) {
+			if _, err := Format(template, testMeta()); err == nil {
+				t.Errorf("expected error for template %q", template)
+			}
+		})
+	}
+}
+
+func TestFormatLiteralPercent(t *testing.T) {
+	got, err := Format("100%% %title%", testMeta())
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	if got != "100% Come Together" {
+		t.Errorf("expected '100%% Come Together', got '%s'", got)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"AC/DC", "AC_DC"},
+		{"What?", "What_"},
+		{"a:b*c\"d<e>f|g\\h", "a_b_c_d_e_f_g_h"},
+		{"  trailing dots... ", "trailing dots"},
+		{"tab\there", "tabhere"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := Sanitize(tt.input); got != tt.expected {
+				t.Errorf("Sanitize(%q) = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTargetEmptyComponent(t *testing.T) {
+	meta := testMeta()
+	meta.AlbumArtist = ""
+
+	if _, err := Target("/music", DefaultTemplate, "/music/a.mp3", meta); err == nil {
+		t.Error("expected error for empty path component")
+	}
+}
+
+func TestTargetKeepsExtension(t *testing.T) {
+	target, err := Target("/music", "%artist% - %title%", "/music/a.mp3", testMeta())
+	if err != nil {
+		t.Fatalf("Target failed: %v", err)
+	}
+	if target != "/music/The Beatles - Come Together.mp3" {
+		t.Errorf("unexpected target '%s'", target)
+	}
+}
+
+func TestNewPlanCollisions(t *testing.T) {
+	dir := t.TempDir()
+	sources := []string{filepath.Join(dir, "a.mp3"), filepath.Join(dir, "b.mp3")}
+
+	plan, err := NewPlan(dir, "%title%.mp3", sources, []*metadata.Metadata{testMeta(), testMeta()})
+	if err != nil {
+		t.Fatalf("NewPlan failed: %v", err)
+	}
+	if len(plan.Collisions) != 1 {
+		t.Errorf("expected 1 collision, got %d", len(plan.Collisions))
+	}
+	if len(plan.Moves) != 1 {
+		t.Errorf("expected 1 move, got %d", len(plan.Moves))
+	}
+}
+
+func TestNewPlanExistingTarget(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "Come Together.mp3")
+	if err := os.WriteFile(existing, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := NewPlan(dir, "%title%.mp3", []string{filepath.Join(dir, "a.mp3")}, []*metadata.Metadata{testMeta()})
+	if err != nil {
+		t.Fatalf("NewPlan failed: %v", err)
+	}
+	if len(plan.Collisions) != 1 || !strings.Contains(plan.Collisions[0], "already exists") {
+		t.Errorf("expected existing target collision, got %v", plan.Collisions)
+	}
+}
+
+func TestNewPlanSkipsUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "Come Together.mp3")
+
+	plan, err := NewPlan(dir, "%title%.mp3", []string{source}, []*metadata.Metadata{testMeta()})
+	if err != nil {
+		t.Fatalf("NewPlan failed: %v", err)
+	}
+	if len(plan.Moves) != 0 {
+		t.Errorf("expected no moves, got %v", plan.Moves)
+	}
+}
+
+func TestApplyAndUndo(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "a.mp3")
+	if err := os.WriteFile(source, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := NewPlan(dir, DefaultTemplate, []string{source}, []*metadata.Metadata{testMeta()})
+	if err != nil {
+		t.Fatalf("NewPlan failed: %v", err)
+	}
+
+	applied, created, err := Apply(plan.Moves)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	target := filepath.Join(dir, "The Beatles", "1969 - Abbey Road", "01 Come Together.mp3")
+	if _, err := os.Stat(target); err != nil {
+		t.Fatalf("expected renamed file at %s", target)
+	}
+
+	if err := Undo(applied, created); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if _, err := os.Stat(source); err != nil {
+		t.Error("expected original file to be restored")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "The Beatles")); !os.IsNotExist(err) {
+		t.Error("expected created directories to be removed")
+	}
+}
+
+func TestUndoFromSubdirectory(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "incoming", "a.mp3")
+	if err := os.MkdirAll(filepath.Dir(source), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(source, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := NewPlan(dir, DefaultTemplate, []string{source}, []*metadata.Metadata{testMeta()})
+	if err != nil {
+		t.Fatalf("NewPlan failed: %v", err)
+	}
+	applied, created, err := Apply(plan.Moves)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if err := Undo(applied, created); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+
+	if _, err := os.Stat(source); err != nil {
+		t.Error("expected original file to be restored")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "The Beatles")); !os.IsNotExist(err) {
+		t.Error("expected the nested folders created by the template to be removed")
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Error("the base directory must be kept")
+	}
+}
+
+func TestUndoRefusesToOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "a.mp3")
+	if err := os.WriteFile(source, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := NewPlan(dir, DefaultTemplate, []string{source}, []*metadata.Metadata{testMeta()})
+	if err != nil {
+		t.Fatalf("NewPlan failed: %v", err)
+	}
+	applied, created, err := Apply(plan.Moves)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if err := os.WriteFile(source, []byte("new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Undo(applied, created); err == nil {
+		t.Fatal("expected Undo to refuse overwriting a new file")
+	}
+	if data, _ := os.ReadFile(source); string(data) != "new" {
+		t.Error("the new file must be kept")
+	}
+}
+
+func TestUndoKeepsExistingDirectories(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "a.mp3")
+	if err := os.WriteFile(source, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	existing := filepath.Join(dir, "The Beatles")
+	if err := os.Mkdir(existing, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := NewPlan(dir, DefaultTemplate, []string{source}, []*metadata.Metadata{testMeta()})
+	if err != nil {
+		t.Fatalf("NewPlan failed: %v", err)
+	}
+	applied, created, err := Apply(plan.Moves)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if err := Undo(applied, created); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+
+	if _, err := os.Stat(existing); err != nil {
+		t.Error("a directory that existed before the rename must be kept")
+	}
+	if _, err := os.Stat(filepath.Join(existing, "1969 - Abbey Road")); !os.IsNotExist(err) {
+		t.Error("expected the created album folder to be removed")
+	}
+}
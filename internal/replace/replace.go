@@ -0,0 +1,70 @@
+package replace
+
+import (
+	"fmt"
+	"regexp"
+
+	"id3v2-tui/internal/metadata"
+)
+
+type Rule struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+	Fields      []string
+}
+
+type Change struct {
+	Path   string
+	Before *metadata.Metadata
+	After  *metadata.Metadata
+	Accept bool
+}
+
+func NewRule(pattern, replacement string, fields []string) (*Rule, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("pattern is empty")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	for _, name := range fields {
+		if !metadata.IsField(name) {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+	}
+	if len(fields) == 0 {
+		fields = metadata.Fields()
+	}
+	return &Rule{Pattern: re, Replacement: replacement, Fields: fields}, nil
+}
+
+func (r *Rule) Apply(meta *metadata.Metadata) *metadata.Metadata {
+	result := meta.Clone()
+	for _, name := range r.Fields {
+		value, _ := result.Get(name)
+		result.Set(name, r.Pattern.ReplaceAllString(value, r.Replacement))
+	}
+	return result
+}
+
+func Preview(r *Rule, paths []string, metas []*metadata.Metadata) []*Change {
+	var changes []*Change
+	for i, path := range paths {
+		after := r.Apply(metas[i])
+		if metas[i].Diff(after) == "" {
+			continue
+		}
+		changes = append(changes, &Change{
+			Path:   path,
+			Before: metas[i],
+			After:  after,
+			Accept: true,
+		})
+	}
+	return changes
+}
+
+func (c *Change) Diff() string {
+	return c.Before.Diff(c.After)
+}
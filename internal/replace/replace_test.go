@@ -0,0 +1,63 @@
+package replace
+
+import (
+	"testing"
+
+	"id3v2-tui/internal/metadata"
+)
+
+func TestNewRuleInvalid(t *testing.T) {
+	if _, err := NewRule("", "x", nil); err == nil {
+		t.Error("expected error for empty pattern")
+	}
+	if _, err := NewRule("(", "x", nil); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+	if _, err := NewRule("a", "x", []string{"bogus"}); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
+
+func TestApplyCaptureGroups(t *testing.T) {
+	rule, err := NewRule(`\s*\((?:feat|ft)\.? ([^)]+)\)`, " feat. $1", []string{"title"})
+	if err != nil {
+		t.Fatalf("NewRule failed: %v", err)
+	}
+
+	meta := &metadata.Metadata{TrackName: "Song (ft Someone)", Artist: "Artist (ft X)"}
+	result := rule.Apply(meta)
+
+	if result.TrackName != "Song feat. Someone" {
+		t.Errorf("expected 'Song feat. Someone', got '%s'", result.TrackName)
+	}
+	if result.Artist != "Artist (ft X)" {
+		t.Errorf("expected artist to be untouched, got '%s'", result.Artist)
+	}
+	if meta.TrackName != "Song (ft Someone)" {
+		t.Error("expected original metadata to be unmodified")
+	}
+}
+
+func TestPreview(t *testing.T) {
+	rule, err := NewRule(" - Remastered$", "", nil)
+	if err != nil {
+		t.Fatalf("NewRule failed: %v", err)
+	}
+
+	paths := []string{"a.mp3", "b.mp3"}
+	metas := []*metadata.Metadata{
+		{TrackName: "Song - Remastered"},
+		{TrackName: "Other"},
+	}
+
+	changes := Preview(rule, paths, metas)
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %d", len(changes))
+	}
+	if changes[0].Path != "a.mp3" || !changes[0].Accept {
+		t.Errorf("unexpected change %+v", changes[0])
+	}
+	if changes[0].Diff() != "Track: Song - Remastered → Song" {
+		t.Errorf("unexpected diff '%s'", changes[0].Diff())
+	}
+}
@@ -0,0 +1,119 @@
+package textops
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+
+	"id3v2-tui/internal/metadata"
+)
+
+type Action struct {
+	Name  string
+	Label string
+	Apply func(s string) string
+}
+
+var DefaultSmallWords = []string{
+	"a", "an", "and", "as", "at", "but", "by", "en", "for", "from", "if", "in",
+	"into", "nor", "of", "on", "or", "per", "the", "to", "vs", "via", "with",
+}
+
+var SmallWords = DefaultSmallWords
+
+var quoteReplacer = strings.NewReplacer(
+	"‘", "'", "’", "'", "‚", "'", "‛", "'", "′", "'",
+	"“", "\"", "”", "\"", "„", "\"", "‟", "\"", "″", "\"",
+)
+
+var actions = []Action{
+	{"title", "Title Case", func(s string) string { return TitleCase(s, SmallWords) }},
+	{"sentence", "Sentence case", SentenceCase},
+	{"upper", "UPPER CASE", strings.ToUpper},
+	{"lower", "lower case", strings.ToLower},
+	{"trim", "Trim whitespace", strings.TrimSpace},
+	{"collapse", "Collapse whitespace", CollapseSpace},
+	{"nfc", "Unicode NFC normalisation", norm.NFC.String},
+	{"quotes", "Straighten curly quotes", StraightenQuotes},
+}
+
+func Actions() []Action {
+	return actions
+}
+
+func Lookup(name string) (Action, bool) {
+	for _, a := range actions {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Action{}, false
+}
+
+func capitalize(word string) string {
+	for i, r := range word {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return word[:i] + string(unicode.ToUpper(r)) + word[i+utf8.RuneLen(r):]
+		}
+	}
+	return word
+}
+
+func TitleCase(s string, smallWords []string) string {
+	small := make(map[string]bool, len(smallWords))
+	for _, w := range smallWords {
+		small[strings.ToLower(w)] = true
+	}
+
+	if !strings.ContainsFunc(s, unicode.IsLower) {
+		s = strings.ToLower(s)
+	}
+	words := strings.Split(s, " ")
+	first, last := -1, -1
+	for i, w := range words {
+		if w != "" {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+
+	for i, w := range words {
+		core := strings.TrimFunc(w, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if small[strings.ToLower(core)] && i != first && i != last && !strings.HasPrefix(w, "(") {
+			words[i] = strings.ToLower(w)
+			continue
+		}
+		if !strings.ContainsFunc(w, unicode.IsUpper) {
+			words[i] = capitalize(w)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func SentenceCase(s string) string {
+	return capitalize(strings.ToLower(s))
+}
+
+func CollapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func StraightenQuotes(s string) string {
+	return quoteReplacer.Replace(s)
+}
+
+func ApplyMetadata(meta *metadata.Metadata, action Action, fields []string) *metadata.Metadata {
+	result := meta.Clone()
+	for _, name := range fields {
+		value, ok := result.Get(name)
+		if !ok {
+			continue
+		}
+		result.Set(name, action.Apply(value))
+	}
+	return result
+}
@@ -0,0 +1,82 @@
+package textops
+
+import (
+	"testing"
+
+	"id3v2-tui/internal/metadata"
+)
+
+func TestTitleCase(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"the long and winding road", "The Long and Winding Road"},
+		{"WHAT A WONDERFUL WORLD", "What a Wonderful World"},
+		{"song (live at the bbc)", "Song (Live at the Bbc)"},
+		{"a day in the life", "A Day in the Life"},
+		{"something to believe in", "Something to Believe In"},
+		{"back in black by AC/DC", "Back in Black by AC/DC"},
+		{"my new iPhone and the BBC", "My New iPhone and the BBC"},
+		{"Live At The McCartney Hall", "Live at the McCartney Hall"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := TitleCase(tt.input, DefaultSmallWords); got != tt.expected {
+				t.Errorf("TitleCase(%q) = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTitleCaseCustomSmallWords(t *testing.T) {
+	if got := TitleCase("king of the hill", []string{"of"}); got != "King of The Hill" {
+		t.Errorf("unexpected result %q", got)
+	}
+}
+
+func TestActions(t *testing.T) {
+	tests := []struct {
+		action   string
+		input    string
+		expected string
+	}{
+		{"sentence", "HELLO THERE World", "Hello there world"},
+		{"upper", "abc", "ABC"},
+		{"lower", "ABC", "abc"},
+		{"trim", "  padded \t", "padded"},
+		{"collapse", " too   many\tspaces ", "too many spaces"},
+		{"nfc", "Cafe\u0301", "Caf\u00e9"},
+		{"quotes", "“Don’t”", "\"Don't\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			action, ok := Lookup(tt.action)
+			if !ok {
+				t.Fatalf("action %q not found", tt.action)
+			}
+			if got := action.Apply(tt.input); got != tt.expected {
+				t.Errorf("%s(%q) = %q, expected %q", tt.action, tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestApplyMetadata(t *testing.T) {
+	action, _ := Lookup("upper")
+	meta := &metadata.Metadata{TrackName: "song", Artist: "artist"}
+
+	result := ApplyMetadata(meta, action, []string{"title"})
+	if result.TrackName != "SONG" || result.Artist != "artist" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if meta.TrackName != "song" {
+		t.Error("expected original metadata to be unmodified")
+	}
+	if meta.Diff(result) != "Track: song → SONG" {
+		t.Errorf("unexpected diff '%s'", meta.Diff(result))
+	}
+}
@@ -0,0 +1,95 @@
+package theme
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+type Themed interface {
+	ApplyTheme()
+}
+
+func fieldStyle() tcell.Style {
+	if Mono() {
+		return tcell.StyleDefault.Underline(true)
+	}
+	return tcell.StyleDefault.Foreground(Text).Background(Secondary)
+}
+
+func buttonStyle() tcell.Style {
+	if Mono() {
+		return tcell.StyleDefault
+	}
+	return tcell.StyleDefault.Foreground(Text).Background(Secondary)
+}
+
+func activatedStyle() tcell.Style {
+	if Mono() {
+		return tcell.StyleDefault.Reverse(true).Bold(true)
+	}
+	return tcell.StyleDefault.Foreground(Background).Background(Text)
+}
+
+func StyleBox(box *tview.Box) {
+	box.SetBackgroundColor(Background)
+	box.SetBorderColor(Primary)
+	box.SetTitleColor(Primary)
+}
+
+func StyleForm(form *tview.Form) {
+	StyleBox(form.Box)
+	form.SetLabelColor(TextDim)
+	form.SetFieldStyle(fieldStyle())
+	form.SetButtonStyle(buttonStyle())
+	form.SetButtonActivatedStyle(activatedStyle())
+}
+
+func StyleList(list *tview.List) {
+	StyleBox(list.Box)
+	list.SetMainTextColor(Text)
+	list.SetSecondaryTextColor(TextDim)
+	list.SetShortcutColor(Primary)
+	list.SetSelectedStyle(activatedStyle())
+}
+
+func StyleTable(table *tview.Table) {
+	StyleBox(table.Box)
+	table.SetSelectedStyle(activatedStyle())
+}
+
+func StyleTextView(view *tview.TextView) {
+	StyleBox(view.Box)
+	view.SetTextColor(Text)
+}
+
+func StyleModal(modal *tview.Modal) {
+	modal.SetBackgroundColor(Background)
+	modal.SetTextColor(Text)
+	modal.SetButtonStyle(buttonStyle())
+	modal.SetButtonActivatedStyle(activatedStyle())
+}
+
+func Apply(p tview.Primitive) {
+	switch w := p.(type) {
+	case Themed:
+		w.ApplyTheme()
+	case *tview.Flex:
+		w.SetBackgroundColor(Background)
+		for i := 0; i < w.GetItemCount(); i++ {
+			Apply(w.GetItem(i))
+		}
+	case *tview.Frame:
+		StyleBox(w.Box)
+		Apply(w.GetPrimitive())
+	case *tview.Form:
+		StyleForm(w)
+	case *tview.List:
+		StyleList(w)
+	case *tview.Table:
+		StyleTable(w)
+	case *tview.TextView:
+		StyleTextView(w)
+	case *tview.Modal:
+		StyleModal(w)
+	}
+}
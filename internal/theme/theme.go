@@ -1,19 +1,181 @@
 package theme
 
-import "github.com/gdamore/tcell/v2"
+import (
+	"fmt"
 
-const (
-	HexPrimary   = "#1C4D8D"
-	HexSecondary = "#0F2854"
-	HexText      = "#BDE8F5"
-	HexTextDim   = "#5A8FBF"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
 )
 
+type Theme struct {
+	Name       string
+	Background tcell.Color
+	Primary    tcell.Color
+	Secondary  tcell.Color
+	Text       tcell.Color
+	TextDim    tcell.Color
+	Error      tcell.Color
+}
+
+var (
+	Dark = Theme{
+		Name:       "dark",
+		Background: tcell.ColorBlack,
+		Primary:    tcell.NewHexColor(0x1C4D8D),
+		Secondary:  tcell.NewHexColor(0x0F2854),
+		Text:       tcell.NewHexColor(0xBDE8F5),
+		TextDim:    tcell.NewHexColor(0x5A8FBF),
+		Error:      tcell.NewHexColor(0xE57373),
+	}
+	Light = Theme{
+		Name:       "light",
+		Background: tcell.NewHexColor(0xFAFAFA),
+		Primary:    tcell.NewHexColor(0x1C4D8D),
+		Secondary:  tcell.NewHexColor(0xD6E4F5),
+		Text:       tcell.NewHexColor(0x1A1A1A),
+		TextDim:    tcell.NewHexColor(0x4A5A70),
+		Error:      tcell.NewHexColor(0xB71C1C),
+	}
+	HighContrast = Theme{
+		Name:       "high-contrast",
+		Background: tcell.ColorBlack,
+		Primary:    tcell.ColorYellow,
+		Secondary:  tcell.ColorNavy,
+		Text:       tcell.ColorWhite,
+		TextDim:    tcell.ColorAqua,
+		Error:      tcell.ColorRed,
+	}
+)
+
+func Builtin() []Theme {
+	return []Theme{Dark, Light, HighContrast}
+}
+
 var (
-	Background = tcell.ColorBlack
-	Primary    = tcell.NewHexColor(0x1C4D8D)
-	Secondary  = tcell.NewHexColor(0x0F2854)
-	Text       = tcell.NewHexColor(0xBDE8F5)
-	TextDim    = tcell.NewHexColor(0x5A8FBF)
-	Error      = tcell.NewHexColor(0xE57373)
+	Background = Dark.Background
+	Primary    = Dark.Primary
+	Secondary  = Dark.Secondary
+	Text       = Dark.Text
+	TextDim    = Dark.TextDim
+	Error      = Dark.Error
+
+	HexPrimary   = Hex(Dark.Primary)
+	HexSecondary = Hex(Dark.Secondary)
+	HexText      = Hex(Dark.Text)
+	HexTextDim   = Hex(Dark.TextDim)
 )
+
+var (
+	available = Builtin()
+	current   = Dark
+	colors    = 1 << 24
+)
+
+func Hex(c tcell.Color) string {
+	if c == tcell.ColorDefault {
+		return "-"
+	}
+	return fmt.Sprintf("#%06X", c.Hex())
+}
+
+func Lookup(name string) (Theme, bool) {
+	for _, t := range available {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Theme{}, false
+}
+
+func Names() []string {
+	names := make([]string, len(available))
+	for i, t := range available {
+		names[i] = t.Name
+	}
+	return names
+}
+
+func SetAvailable(themes []Theme) {
+	available = themes
+}
+
+func Current() Theme {
+	return current
+}
+
+func Mono() bool {
+	return colors == 0
+}
+
+func Set(t Theme) {
+	current = t
+	t = limit(t)
+
+	Background, Primary, Secondary = t.Background, t.Primary, t.Secondary
+	Text, TextDim, Error = t.Text, t.TextDim, t.Error
+	HexPrimary, HexSecondary = Hex(Primary), Hex(Secondary)
+	HexText, HexTextDim = Hex(Text), Hex(TextDim)
+
+	tview.Styles.PrimitiveBackgroundColor = Background
+	tview.Styles.ContrastBackgroundColor = Secondary
+	tview.Styles.MoreContrastBackgroundColor = Primary
+	tview.Styles.BorderColor = Primary
+	tview.Styles.TitleColor = Primary
+	tview.Styles.GraphicsColor = Primary
+	tview.Styles.PrimaryTextColor = Text
+	tview.Styles.SecondaryTextColor = TextDim
+	tview.Styles.TertiaryTextColor = TextDim
+	tview.Styles.InverseTextColor = Background
+	tview.Styles.ContrastSecondaryTextColor = TextDim
+}
+
+func Next() Theme {
+	for i, t := range available {
+		if t.Name == current.Name {
+			return available[(i+1)%len(available)]
+		}
+	}
+	return available[0]
+}
+
+func SetColors(n int) bool {
+	if n == colors {
+		return false
+	}
+	colors = n
+	Set(current)
+	return true
+}
+
+func limit(t Theme) Theme {
+	switch {
+	case colors == 0:
+		return Theme{
+			Name:       t.Name,
+			Background: tcell.ColorDefault,
+			Primary:    tcell.ColorDefault,
+			Secondary:  tcell.ColorDefault,
+			Text:       tcell.ColorDefault,
+			TextDim:    tcell.ColorDefault,
+			Error:      tcell.ColorDefault,
+		}
+	case colors < 256:
+		palette := make([]tcell.Color, 0, 16)
+		for i := 0; i < 16 && i < colors; i++ {
+			palette = append(palette, tcell.PaletteColor(i))
+		}
+		fit := func(c tcell.Color) tcell.Color {
+			return tcell.FindColor(c, palette)
+		}
+		return Theme{
+			Name:       t.Name,
+			Background: fit(t.Background),
+			Primary:    fit(t.Primary),
+			Secondary:  fit(t.Secondary),
+			Text:       fit(t.Text),
+			TextDim:    fit(t.TextDim),
+			Error:      fit(t.Error),
+		}
+	}
+	return t
+}
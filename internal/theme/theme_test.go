@@ -0,0 +1,65 @@
+package theme
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func restore(t *testing.T) {
+	t.Cleanup(func() {
+		SetAvailable(Builtin())
+		SetColors(1 << 24)
+		Set(Dark)
+	})
+}
+
+func TestSetUpdatesColors(t *testing.T) {
+	restore(t)
+
+	Set(Light)
+	if Background != Light.Background || Text != Light.Text {
+		t.Error("expected light colors")
+	}
+	if HexText != "#1A1A1A" {
+		t.Errorf("unexpected hex %q", HexText)
+	}
+}
+
+func TestNext(t *testing.T) {
+	restore(t)
+
+	Set(Dark)
+	if Next().Name != "light" {
+		t.Errorf("expected light after dark, got %q", Next().Name)
+	}
+	Set(HighContrast)
+	if Next().Name != "dark" {
+		t.Errorf("expected wrap-around to dark, got %q", Next().Name)
+	}
+}
+
+func TestSetColors(t *testing.T) {
+	restore(t)
+	Set(Dark)
+
+	if !SetColors(16) {
+		t.Fatal("expected a change")
+	}
+	if Primary.Hex() == Dark.Primary.Hex() {
+		t.Error("expected the palette to be reduced")
+	}
+	for _, c := range []tcell.Color{Primary, Secondary, Text, TextDim} {
+		if c < tcell.ColorBlack || c > tcell.ColorWhite {
+			t.Errorf("%v is not one of the 16 basic colors", c)
+		}
+	}
+
+	SetColors(0)
+	if !Mono() || Text != tcell.ColorDefault || HexPrimary != "-" {
+		t.Error("expected no colors with NO_COLOR")
+	}
+	if Current().Name != "dark" {
+		t.Error("the selected theme should be kept")
+	}
+}
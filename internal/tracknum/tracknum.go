@@ -0,0 +1,147 @@
+package tracknum
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"id3v2-tui/internal/files"
+)
+
+type Assignment struct {
+	Path  string
+	Track string
+	Disc  string
+}
+
+var discPattern = regexp.MustCompile(`(?i)^(?:cd|disc|disk)\s*[-_.]?\s*(\d+)\b`)
+
+func DiscNumber(dir string) (int, bool) {
+	m := discPattern.FindStringSubmatch(filepath.Base(dir))
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil || n == 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+func discTotal(parent string) int {
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		return 0
+	}
+	total := 0
+	for _, entry := range entries {
+		if _, ok := DiscNumber(entry.Name()); ok && entry.IsDir() {
+			total++
+		}
+	}
+	return total
+}
+
+func Collect(dir string) ([]string, error) {
+	paths, err := files.ListAudioFiles(dir, false)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, ok := DiscNumber(entry.Name()); !ok {
+			continue
+		}
+		discPaths, err := files.ListAudioFiles(filepath.Join(dir, entry.Name()), false)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, discPaths...)
+	}
+	return paths, nil
+}
+
+func Plan(paths []string, natural bool) []Assignment {
+	type group struct {
+		disc  int
+		paths []string
+	}
+	var groups []*group
+	byDisc := make(map[int]*group)
+	multiDisc := false
+
+	for _, path := range paths {
+		disc, ok := DiscNumber(filepath.Dir(path))
+		if ok {
+			multiDisc = true
+		}
+		g, exists := byDisc[disc]
+		if !exists {
+			g = &group{disc: disc}
+			byDisc[disc] = g
+			groups = append(groups, g)
+		}
+		g.paths = append(g.paths, path)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].disc < groups[j].disc
+	})
+
+	totals := make(map[string]int)
+	var assignments []Assignment
+	for _, g := range groups {
+		if natural {
+			sort.SliceStable(g.paths, func(i, j int) bool {
+				return files.NaturalLess(filepath.Base(g.paths[i]), filepath.Base(g.paths[j]))
+			})
+		}
+		for i, path := range g.paths {
+			a := Assignment{
+				Path:  path,
+				Track: fmt.Sprintf("%d/%d", i+1, len(g.paths)),
+			}
+			if multiDisc && g.disc > 0 {
+				parent := filepath.Dir(filepath.Dir(path))
+				total, ok := totals[parent]
+				if !ok {
+					total = discTotal(parent)
+					totals[parent] = total
+				}
+				a.Disc = strconv.Itoa(g.disc)
+				if total >= g.disc {
+					a.Disc += "/" + strconv.Itoa(total)
+				}
+			}
+			assignments = append(assignments, a)
+		}
+	}
+	return assignments
+}
+
+func Preview(base string, assignments []Assignment) string {
+	lines := make([]string, len(assignments))
+	for i, a := range assignments {
+		name := a.Path
+		if rel, err := filepath.Rel(base, a.Path); err == nil {
+			name = rel
+		}
+		line := fmt.Sprintf("%s → track %s", name, a.Track)
+		if a.Disc != "" {
+			line += ", disc " + a.Disc
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,119 @@
+package tracknum
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiscNumber(t *testing.T) {
+	tests := []struct {
+		dir      string
+		expected int
+		ok       bool
+	}{
+		{"CD1", 1, true},
+		{"cd 2", 2, true},
+		{"Disc 2", 2, true},
+		{"disk_03", 3, true},
+		{"Disc 1 - Bonus", 1, true},
+		{"Album", 0, false},
+		{"CD0", 0, false},
+		{"Discography", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			n, ok := DiscNumber(filepath.Join("/music", tt.dir))
+			if n != tt.expected || ok != tt.ok {
+				t.Errorf("DiscNumber(%q) = %d, %v, expected %d, %v", tt.dir, n, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestPlanListOrder(t *testing.T) {
+	paths := []string{"/a/b.mp3", "/a/a.mp3", "/a/c.mp3"}
+
+	assignments := Plan(paths, false)
+	if len(assignments) != 3 {
+		t.Fatalf("expected 3 assignments, got %d", len(assignments))
+	}
+	if assignments[0].Path != "/a/b.mp3" || assignments[0].Track != "1/3" {
+		t.Errorf("unexpected first assignment %+v", assignments[0])
+	}
+	if assignments[2].Track != "3/3" || assignments[2].Disc != "" {
+		t.Errorf("unexpected last assignment %+v", assignments[2])
+	}
+}
+
+func TestPlanNaturalOrder(t *testing.T) {
+	paths := []string{"/a/track10.mp3", "/a/track2.mp3", "/a/track1.mp3"}
+
+	assignments := Plan(paths, true)
+	expected := []string{"/a/track1.mp3", "/a/track2.mp3", "/a/track10.mp3"}
+	for i, a := range assignments {
+		if a.Path != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], a.Path)
+		}
+	}
+}
+
+func TestPlanMultiDisc(t *testing.T) {
+	album := t.TempDir()
+	for _, sub := range []string{"CD1", "CD2", "Artwork"} {
+		if err := os.Mkdir(filepath.Join(album, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	paths := []string{
+		filepath.Join(album, "CD2", "01.mp3"),
+		filepath.Join(album, "CD1", "01.mp3"),
+		filepath.Join(album, "CD1", "02.mp3"),
+	}
+
+	assignments := Plan(paths, true)
+	if len(assignments) != 3 {
+		t.Fatalf("expected 3 assignments, got %d", len(assignments))
+	}
+	if assignments[0].Path != paths[1] || assignments[0].Track != "1/2" || assignments[0].Disc != "1/2" {
+		t.Errorf("unexpected first assignment %+v", assignments[0])
+	}
+	if assignments[2].Path != paths[0] || assignments[2].Track != "1/1" || assignments[2].Disc != "2/2" {
+		t.Errorf("unexpected last assignment %+v", assignments[2])
+	}
+
+	if a := Plan(paths[:1], true); a[0].Disc != "2/2" {
+		t.Errorf("numbering one disc should count its siblings, got %+v", a[0])
+	}
+
+	lone := filepath.Join(t.TempDir(), "CD2")
+	if err := os.Mkdir(lone, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if a := Plan([]string{filepath.Join(lone, "01.mp3")}, true); a[0].Disc != "2" {
+		t.Errorf("a lone second disc should leave the total empty, got %+v", a[0])
+	}
+}
+
+func TestCollect(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"CD1", "Disc 2", "Artwork"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"bonus.mp3", "CD1/a.mp3", "Disc 2/b.mp3", "Artwork/c.mp3"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, err := Collect(dir)
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if len(paths) != 3 {
+		t.Errorf("expected 3 files, got %v", paths)
+	}
+}
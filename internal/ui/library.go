@@ -0,0 +1,210 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/rivo/tview"
+
+	"id3v2-tui/internal/library"
+	"id3v2-tui/internal/theme"
+)
+
+var columnTitles = map[string]string{
+	"title":    "Title",
+	"artist":   "Artist",
+	"album":    "Album",
+	"track":    "#",
+	"year":     "Year",
+	"duration": "Time",
+	"cover":    "Cover",
+}
+
+type LibraryTable struct {
+	*tview.Table
+	Entries    []library.Entry
+	Column     string
+	Descending bool
+	Query      library.Query
+	Marked     func(path string) bool
+	rows       []library.Entry
+	dir        string
+}
+
+func CreateLibraryTable() *LibraryTable {
+	t := &LibraryTable{
+		Table:  tview.NewTable().SetFixed(1, 0).SetSelectable(true, false),
+		Column: "title",
+	}
+	t.SetBorder(true)
+	t.ApplyTheme()
+	return t
+}
+
+func (t *LibraryTable) ApplyTheme() {
+	theme.StyleTable(t.Table)
+	t.render()
+}
+
+func (t *LibraryTable) Reset(dir string) {
+	t.dir = dir
+	t.Entries = nil
+	t.Select(1, 0)
+	t.render()
+}
+
+func (t *LibraryTable) Add(entries ...library.Entry) {
+	t.Entries = append(t.Entries, entries...)
+	for _, e := range entries {
+		if t.Query.Match(t.name(e.Path), &e) {
+			t.rows = append(t.rows, e)
+			t.renderRow(len(t.rows)-1, e)
+		}
+	}
+	t.renderTitle()
+}
+
+func (t *LibraryTable) Update(entries ...library.Entry) {
+	changed := false
+	for _, e := range entries {
+		for i := range t.Entries {
+			if t.Entries[i].Path == e.Path {
+				t.Entries[i] = e
+				changed = true
+			}
+		}
+	}
+	if changed {
+		t.SortBy(t.Column, t.Descending)
+	}
+}
+
+func (t *LibraryTable) SetQuery(q library.Query) {
+	path := t.SelectedPath()
+	t.Query = q
+	t.render()
+	t.Select(1, 0)
+	t.selectPath(path)
+}
+
+func (t *LibraryTable) SortBy(column string, descending bool) {
+	path := t.SelectedPath()
+	t.Column = column
+	t.Descending = descending
+	library.Sort(t.Entries, t.Column, t.Descending)
+	t.render()
+	t.selectPath(path)
+}
+
+func (t *LibraryTable) SelectedPath() string {
+	row, _ := t.GetSelection()
+	if row < 1 || row > len(t.rows) {
+		return ""
+	}
+	return t.rows[row-1].Path
+}
+
+func (t *LibraryTable) SelectedIndex() int {
+	row, _ := t.GetSelection()
+	return row - 1
+}
+
+func (t *LibraryTable) SelectIndex(index int) {
+	if len(t.rows) == 0 {
+		return
+	}
+	t.Select(min(max(index, 0), len(t.rows)-1)+1, 0)
+}
+
+func (t *LibraryTable) Move(offset int) {
+	t.SelectIndex(t.SelectedIndex() + offset)
+}
+
+func (t *LibraryTable) Names() []string {
+	names := make([]string, len(t.rows))
+	for i, e := range t.rows {
+		names[i] = t.name(e.Path)
+	}
+	return names
+}
+
+func (t *LibraryTable) name(path string) string {
+	if rel, err := filepath.Rel(t.dir, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+func (t *LibraryTable) selectPath(path string) {
+	for i, e := range t.rows {
+		if e.Path == path {
+			t.Select(i+1, 0)
+			return
+		}
+	}
+}
+
+func (t *LibraryTable) Refresh() {
+	t.render()
+}
+
+func (t *LibraryTable) render() {
+	t.Clear()
+	t.rows = t.rows[:0]
+	for i := range t.Entries {
+		if t.Query.Match(t.name(t.Entries[i].Path), &t.Entries[i]) {
+			t.rows = append(t.rows, t.Entries[i])
+		}
+	}
+	t.renderTitle()
+
+	t.SetCell(0, 0, tview.NewTableCell(" ").SetSelectable(false))
+	t.SetCell(0, 1, tview.NewTableCell("File").SetTextColor(theme.Primary).SetSelectable(false))
+	for i, column := range library.Columns {
+		title := columnTitles[column]
+		if column == t.Column {
+			if t.Descending {
+				title += " ▼"
+			} else {
+				title += " ▲"
+			}
+		}
+		t.SetCell(0, i+2, tview.NewTableCell(title).SetTextColor(theme.Primary).SetSelectable(false))
+	}
+
+	for row, e := range t.rows {
+		t.renderRow(row, e)
+	}
+}
+
+func (t *LibraryTable) renderTitle() {
+	if t.Query.Empty() {
+		t.SetTitle(fmt.Sprintf("Library - %s (%d files)", filepath.Base(t.dir), len(t.Entries)))
+	} else {
+		t.SetTitle(fmt.Sprintf("Library - %s (%d of %d files, /%s)", filepath.Base(t.dir), len(t.rows), len(t.Entries), tview.Escape(t.Query.String())))
+	}
+}
+
+func (t *LibraryTable) renderRow(row int, e library.Entry) {
+	mark := " "
+	if t.Marked != nil && t.Marked(e.Path) {
+		mark = "✓"
+	}
+	name := t.name(e.Path)
+	color := theme.Text
+	if e.Err != nil {
+		color = theme.Error
+	}
+	t.SetCell(row+1, 0, tview.NewTableCell(mark).SetTextColor(theme.Primary))
+	t.SetCell(row+1, 1, tview.NewTableCell(tview.Escape(name)).SetTextColor(color).SetMaxWidth(40))
+	for i, column := range library.Columns {
+		cell := tview.NewTableCell(tview.Escape(e.Value(column))).SetTextColor(theme.Text)
+		switch column {
+		case "title", "artist", "album":
+			cell.SetExpansion(1).SetMaxWidth(30)
+		case "track", "duration":
+			cell.SetAlign(tview.AlignRight)
+		}
+		t.SetCell(row+1, i+2, cell)
+	}
+}
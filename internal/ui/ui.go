@@ -1,16 +1,18 @@
 package ui
 
 import (
-	"path/filepath"
-	"strings"
-
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 
+	"id3v2-tui/internal/actions"
+	"id3v2-tui/internal/keymap"
+	"id3v2-tui/internal/metadata"
 	"id3v2-tui/internal/theme"
+	"id3v2-tui/internal/vim"
 )
 
-type SaveCallback func(filePath, trackName, artist, album, coverPath string) (string, error)
+type SaveCallback func(filePath string, values map[string]string) (string, error)
+type ConfirmSaveFunc func(filePath string, values map[string]string, apply func())
 type GetRootFunc func() tview.Primitive
 type ShowErrorFunc func(msg string)
 type ShowMessageFunc func(msg string)
@@ -20,206 +22,392 @@ type GetCurrentDirFunc func() string
 type SetCurrentDirFunc func(string)
 type GetFocusIndexFunc func() int
 type SetFocusIndexFunc func(int)
+type ActionFunc func()
+type ShowHelpFunc func(text string)
+type OpenCommandLineFunc func(prompt string, onSubmit func(text string))
+type ShowPaletteFunc func(context keymap.Context, registry *actions.Registry)
 
 type UIContext struct {
-	App           *tview.Application
-	GetRoot       GetRootFunc
-	ShowError     ShowErrorFunc
-	ShowMessage   ShowMessageFunc
-	GetForm       GetFormFunc
-	GetFileList   GetFileListFunc
-	GetCurrentDir GetCurrentDirFunc
-	SetCurrentDir SetCurrentDirFunc
-	GetFocusIndex GetFocusIndexFunc
-	SetFocusIndex SetFocusIndexFunc
-	SaveMetadata  SaveCallback
-	CurrentFile   string
+	App             *tview.Application
+	GetRoot         GetRootFunc
+	ShowError       ShowErrorFunc
+	ShowMessage     ShowMessageFunc
+	GetForm         GetFormFunc
+	GetFileList     GetFileListFunc
+	GetBrowser      func() tview.Primitive
+	GetCurrentFile  func() string
+	GetCurrentDir   GetCurrentDirFunc
+	SetCurrentDir   SetCurrentDirFunc
+	GetFocusIndex   GetFocusIndexFunc
+	SetFocusIndex   SetFocusIndexFunc
+	SaveMetadata    SaveCallback
+	ConfirmSave     ConfirmSaveFunc
+	RenameFromTags  ActionFunc
+	UndoRename      ActionFunc
+	ToggleSelection ActionFunc
+	FindReplace     ActionFunc
+	TextActions     ActionFunc
+	FixEncoding     ActionFunc
+	NumberTracks    ActionFunc
+	ExportCSV       ActionFunc
+	ImportCSV       ActionFunc
+	OpenInEditor    ActionFunc
+	ExportCover     ActionFunc
+	ConvertVersion  ActionFunc
+	StripTags       ActionFunc
+	ToggleLibrary   ActionFunc
+	Filter          ActionFunc
+	ToggleHidden    ActionFunc
+	Sort            ActionFunc
+	EditSelection   ActionFunc
+	SaveBatch       func(values map[string]string, then func()) bool
+	ToggleLog       ActionFunc
+	NextTheme       ActionFunc
+	ShowHelp        ShowHelpFunc
+	ShowPalette     ShowPaletteFunc
+	Quit            ActionFunc
+	FormChanged     ActionFunc
+	Keymap          *keymap.Keymap
+	Actions         *actions.Registry
+	Vim             *vim.State
+	ModeChanged     ActionFunc
+	OpenCommandLine OpenCommandLineFunc
+	LoadDirectory   func(dir string)
+	PreviewRename   func(template string)
+	FormFields      []string
+	CurrentFile     string
+}
+
+var fieldLabels = map[string]string{
+	"title":       "Track Name",
+	"artist":      "Artist",
+	"album":       "Album",
+	"albumartist": "Album Artist",
+	"year":        "Year",
+	"track":       "Track Number",
+	"disc":        "Disc Number",
+	"comment":     "Comment",
+	"cover":       "Cover Image Path",
+}
+
+func formFieldNames() []string {
+	return append(metadata.Fields(), "cover")
+}
+
+func FieldLabel(name string) string {
+	return fieldLabels[name]
 }
 
 func CreateFileBrowser(ctx *UIContext) *tview.List {
 	list := tview.NewList()
 	list.SetBorder(true).SetTitle("Files")
-	list.SetMainTextColor(theme.Text)
-	list.SetSecondaryTextColor(theme.TextDim)
-	list.SetTitleColor(theme.Primary)
-	list.SetBorderColor(theme.Primary)
+	theme.StyleList(list)
 	return list
 }
 
 func CreateMetadataForm(directMode bool, ctx *UIContext) *tview.Form {
 	form := tview.NewForm()
 	form.SetBorder(true).SetTitle("Metadata Editor")
-	form.SetTitleColor(theme.Secondary)
-	form.SetBorderColor(theme.Primary)
-	form.SetLabelColor(theme.TextDim)
-	form.SetFieldTextColor(theme.Text)
-	form.SetFieldBackgroundColor(theme.Secondary)
+	theme.StyleForm(form)
 
-	form.AddInputField("Track Name", "", 40, nil, nil)
-	form.AddInputField("Artist", "", 40, nil, nil)
-	form.AddInputField("Album", "", 40, nil, nil)
-	form.AddInputField("Cover Image Path", "", 40, nil, nil)
+	changed := func(string) {
+		if ctx.FormChanged != nil {
+			ctx.FormChanged()
+		}
+	}
+	for _, name := range ctx.FormFields {
+		form.AddInputField(FieldLabel(name), "", 40, nil, changed)
+	}
 
 	form.AddButton("Save", func() {
-		var filePath string
-		if directMode {
-			filePath = ctx.CurrentFile
-		} else {
-			fileList := ctx.GetFileList()
-			selectedItem := fileList.GetCurrentItem()
-			if selectedItem >= 0 {
-				mainText, _ := fileList.GetItemText(selectedItem)
-				if mainText != "" && !strings.HasSuffix(mainText, "/") && mainText != ".." {
-					filePath = filepath.Join(ctx.GetCurrentDir(), mainText)
-				}
-			}
-		}
+		registry(directMode, ctx).Run("save")
+	})
 
-		if filePath == "" {
-			return
-		}
+	form.AddButton("Clear", func() {
+		registry(directMode, ctx).Run("clear_form")
+	})
 
-		trackName := form.GetFormItemByLabel("Track Name").(*tview.InputField).GetText()
-		artist := form.GetFormItemByLabel("Artist").(*tview.InputField).GetText()
-		album := form.GetFormItemByLabel("Album").(*tview.InputField).GetText()
-		coverPath := form.GetFormItemByLabel("Cover Image Path").(*tview.InputField).GetText()
+	form.SetButtonsAlign(tview.AlignCenter)
 
-		diff, err := ctx.SaveMetadata(filePath, trackName, artist, album, coverPath)
-		if err != nil {
+	return form
+}
+
+func saveForm(directMode bool, ctx *UIContext, then func()) {
+	values := FormValues(ctx.GetForm())
+	if ctx.SaveBatch != nil && ctx.SaveBatch(values, then) {
+		return
+	}
+
+	filePath := ctx.CurrentFile
+	if ctx.GetCurrentFile != nil {
+		filePath = ctx.GetCurrentFile()
+	}
+
+	if filePath == "" {
+		return
+	}
+
+	save := func() {
+		diff, err := ctx.SaveMetadata(filePath, values)
+		switch {
+		case err != nil:
 			ctx.ShowError(err.Error())
-		} else if diff != "" {
-			ctx.ShowMessage("Metadata saved successfully!\n\n" + diff)
-		} else {
+		case then != nil:
+			then()
+		case diff == "":
 			ctx.ShowMessage("No changes detected")
+		case ctx.ConfirmSave == nil:
+			ctx.ShowMessage("Metadata saved successfully!\n\n" + diff)
 		}
-	})
+	}
+	if ctx.ConfirmSave == nil {
+		save()
+		return
+	}
+	ctx.ConfirmSave(filePath, values, save)
+}
 
-	form.AddButton("Clear", func() {
-		form.GetFormItemByLabel("Track Name").(*tview.InputField).SetText("")
-		form.GetFormItemByLabel("Artist").(*tview.InputField).SetText("")
-		form.GetFormItemByLabel("Album").(*tview.InputField).SetText("")
-		form.GetFormItemByLabel("Cover Image Path").(*tview.InputField).SetText("")
-	})
+func focusNext(directMode bool, ctx *UIContext, step int) {
+	form := ctx.GetForm()
+	totalItems := form.GetFormItemCount() + form.GetButtonCount()
+	if !directMode {
+		totalItems++
+	}
 
-	form.SetButtonsAlign(tview.AlignCenter)
-	form.SetButtonBackgroundColor(theme.Secondary)
-	form.SetButtonTextColor(theme.Text)
+	focusAt(directMode, ctx, (ctx.GetFocusIndex()+step+totalItems)%totalItems)
+}
 
-	return form
+func focusField(directMode bool, ctx *UIContext, field int) {
+	if !directMode {
+		field++
+	}
+	focusAt(directMode, ctx, field)
+}
+
+func focusAt(directMode bool, ctx *UIContext, focusIndex int) {
+	form := ctx.GetForm()
+	numFormItems := form.GetFormItemCount()
+	ctx.SetFocusIndex(focusIndex)
+
+	idx := focusIndex
+	if !directMode {
+		if focusIndex == 0 {
+			ctx.App.SetFocus(browser(ctx))
+			return
+		}
+		idx--
+	}
+	if idx < numFormItems {
+		ctx.App.SetFocus(form.GetFormItem(idx))
+	} else {
+		ctx.App.SetFocus(form.GetButton(idx - numFormItems))
+	}
+}
+
+func focusContext(directMode bool, ctx *UIContext) keymap.Context {
+	if !directMode && ctx.App.GetFocus() == browser(ctx) {
+		return keymap.ContextList
+	}
+	return keymap.ContextForm
+}
+
+func browser(ctx *UIContext) tview.Primitive {
+	if ctx.GetBrowser != nil {
+		return ctx.GetBrowser()
+	}
+	return ctx.GetFileList()
+}
+
+func registry(directMode bool, ctx *UIContext) *actions.Registry {
+	if ctx.Actions != nil {
+		return ctx.Actions
+	}
+
+	r := actions.NewRegistry()
+	ctx.Actions = r
+	r.Register("save", func() { saveForm(directMode, ctx, nil) })
+	r.Register("clear_form", func() { ClearForm(ctx.GetForm()) })
+	r.Register("focus_next", func() { focusNext(directMode, ctx, 1) })
+	r.Register("focus_prev", func() { focusNext(directMode, ctx, -1) })
+	if !directMode {
+		r.Register("toggle_selection", ctx.ToggleSelection)
+		r.Register("edit_selection", ctx.EditSelection)
+		r.Register("rename", ctx.RenameFromTags)
+		r.Register("undo_rename", ctx.UndoRename)
+		r.Register("find_replace", ctx.FindReplace)
+		r.Register("number_tracks", ctx.NumberTracks)
+		r.Register("export_csv", ctx.ExportCSV)
+		r.Register("import_csv", ctx.ImportCSV)
+		r.Register("toggle_library", ctx.ToggleLibrary)
+		r.Register("filter", ctx.Filter)
+		r.Register("sort", ctx.Sort)
+		r.Register("toggle_hidden", ctx.ToggleHidden)
+	}
+	r.Register("text_actions", ctx.TextActions)
+	r.Register("fix_encoding", ctx.FixEncoding)
+	r.Register("edit_in_editor", ctx.OpenInEditor)
+	r.Register("export_cover", ctx.ExportCover)
+	r.Register("convert_version", ctx.ConvertVersion)
+	r.Register("strip", ctx.StripTags)
+	r.Register("toggle_log", ctx.ToggleLog)
+	r.Register("next_theme", ctx.NextTheme)
+	if ctx.ShowHelp != nil {
+		r.Register("help", func() {
+			help := ctx.Keymap.Help(func(action string) bool {
+				a, _ := keymap.LookupAction(action)
+				return a.Modal || r.Has(action)
+			})
+			if ctx.Vim != nil {
+				help += "\n" + vim.Help
+			}
+			ctx.ShowHelp(help)
+		})
+	}
+	if ctx.ShowPalette != nil {
+		r.Register("command_palette", func() {
+			ctx.ShowPalette(focusContext(directMode, ctx), r)
+		})
+	}
+	r.Register("quit", ctx.Quit)
+	return r
 }
 
 func CreateInputCapture(directMode bool, ctx *UIContext) func(event *tcell.EventKey) *tcell.EventKey {
+	r := registry(directMode, ctx)
 	return func(event *tcell.EventKey) *tcell.EventKey {
-		if event.Rune() == 'q' {
-			ctx.App.Stop()
+		focus := ctx.App.GetFocus()
+		if _, ok := focus.(*CommandLine); ok {
+			return event
+		}
+		if ctx.Vim != nil && handleVim(directMode, ctx, event) {
 			return nil
 		}
-		if event.Key() == tcell.KeyTab {
-			form := ctx.GetForm()
-			fileList := ctx.GetFileList()
-			numFormItems := form.GetFormItemCount()
-			numButtons := form.GetButtonCount()
-			totalItems := numFormItems + numButtons
-
-			if !directMode {
-				totalItems++
-			}
 
-			focusIndex := ctx.GetFocusIndex()
-			focusIndex = (focusIndex + 1) % totalItems
-			ctx.SetFocusIndex(focusIndex)
-
-			if !directMode && focusIndex == 0 {
-				ctx.App.SetFocus(fileList)
-			} else {
-				idx := focusIndex
-				if !directMode {
-					idx--
-				}
-				if idx < numFormItems {
-					ctx.App.SetFocus(form.GetFormItem(idx))
-				} else {
-					ctx.App.SetFocus(form.GetButton(idx - numFormItems))
-				}
-			}
+		_, typing := focus.(*tview.InputField)
+		normal := ctx.Vim != nil && ctx.Vim.Mode == vim.Normal
+
+		action, ok := ctx.Keymap.Lookup(focusContext(directMode, ctx), event, typing && !normal)
+		if ok && r.Run(action) {
 			return nil
 		}
-		if event.Key() == tcell.KeyBacktab {
-			form := ctx.GetForm()
-			fileList := ctx.GetFileList()
-			numFormItems := form.GetFormItemCount()
-			numButtons := form.GetButtonCount()
-			totalItems := numFormItems + numButtons
-
-			if !directMode {
-				totalItems++
+		if typing && normal {
+			switch event.Key() {
+			case tcell.KeyRune, tcell.KeyBackspace, tcell.KeyBackspace2, tcell.KeyDelete:
+				return nil
 			}
+		}
+		return event
+	}
+}
 
-			focusIndex := ctx.GetFocusIndex()
-			focusIndex--
-			if focusIndex < 0 {
-				focusIndex = totalItems - 1
-			}
-			ctx.SetFocusIndex(focusIndex)
-
-			if !directMode && focusIndex == 0 {
-				ctx.App.SetFocus(fileList)
-			} else {
-				idx := focusIndex
-				if !directMode {
-					idx--
-				}
-				if idx < 0 {
-					ctx.App.SetFocus(form.GetButton(numButtons - 1))
-				} else if idx < numFormItems {
-					ctx.App.SetFocus(form.GetFormItem(idx))
-				} else {
-					ctx.App.SetFocus(form.GetButton(idx - numFormItems))
-				}
+func inputField(form *tview.Form, name string) *tview.InputField {
+	field, _ := form.GetFormItemByLabel(FieldLabel(name)).(*tview.InputField)
+	return field
+}
+
+func fieldValue(meta *metadata.Metadata, name string) string {
+	if name == "cover" {
+		return meta.CoverPath
+	}
+	value, _ := meta.Get(name)
+	return value
+}
+
+func PopulateForm(form *tview.Form, meta *metadata.Metadata) {
+	for _, name := range formFieldNames() {
+		if field := inputField(form, name); field != nil {
+			field.SetText(fieldValue(meta, name))
+		}
+	}
+}
+
+func SetFormDisabled(form *tview.Form, disabled bool) {
+	for _, name := range formFieldNames() {
+		if field := inputField(form, name); field != nil {
+			field.SetDisabled(disabled)
+		}
+	}
+}
+
+func SetMixed(form *tview.Form, names []string) {
+	mixed := make(map[string]bool, len(names))
+	for _, name := range names {
+		mixed[name] = true
+	}
+	for _, name := range formFieldNames() {
+		if field := inputField(form, name); field != nil {
+			placeholder := ""
+			if mixed[name] {
+				placeholder = "(multiple values)"
 			}
-			return nil
+			field.SetPlaceholder(placeholder).SetPlaceholderTextColor(theme.TextDim)
 		}
-		if event.Key() == tcell.KeyEsc {
-			form := ctx.GetForm()
-			form.GetFormItemByLabel("Track Name").(*tview.InputField).SetText("")
-			form.GetFormItemByLabel("Artist").(*tview.InputField).SetText("")
-			form.GetFormItemByLabel("Album").(*tview.InputField).SetText("")
-			form.GetFormItemByLabel("Cover Image Path").(*tview.InputField).SetText("")
-			return nil
+	}
+}
+
+func ClearForm(form *tview.Form) {
+	PopulateForm(form, &metadata.Metadata{})
+}
+
+func FormValues(form *tview.Form) map[string]string {
+	values := make(map[string]string)
+	for _, name := range formFieldNames() {
+		if field := inputField(form, name); field != nil {
+			values[name] = field.GetText()
 		}
-		return event
 	}
+	return values
 }
 
-func PopulateForm(form *tview.Form, trackName, artist, album, coverPath string) {
-	form.GetFormItemByLabel("Track Name").(*tview.InputField).SetText(trackName)
-	form.GetFormItemByLabel("Artist").(*tview.InputField).SetText(artist)
-	form.GetFormItemByLabel("Album").(*tview.InputField).SetText(album)
-	form.GetFormItemByLabel("Cover Image Path").(*tview.InputField).SetText(coverPath)
+type StatusBar struct {
+	*tview.TextView
 }
 
-func CreateStatusBar(text string) *tview.TextView {
-	statusBar := tview.NewTextView().
+func (s *StatusBar) ApplyTheme() {
+	theme.StyleTextView(s.TextView)
+	s.SetTextColor(theme.TextDim)
+}
+
+func CreateStatusBar(text string) *StatusBar {
+	statusBar := &StatusBar{tview.NewTextView().
 		SetText(text).
-		SetTextColor(theme.TextDim).
-		SetTextAlign(tview.AlignCenter)
+		SetTextAlign(tview.AlignCenter)}
 	statusBar.SetBorder(false)
+	statusBar.ApplyTheme()
 	return statusBar
 }
 
-func CreateFileListWrapper(fileList *tview.List) *tview.Frame {
-	title := "[" + theme.HexPrimary + "]Files[" + theme.HexText + "]"
-	fileListWrapper := tview.NewFrame(fileList).
-		AddText(title, true, tview.AlignLeft, theme.Primary).
+func CreateLogPanel() *tview.TextView {
+	logPanel := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true)
+	logPanel.SetBorder(true).SetTitle("Log")
+	theme.StyleTextView(logPanel)
+	return logPanel
+}
+
+type Wrapper struct {
+	*tview.Frame
+	header string
+}
+
+func (w *Wrapper) ApplyTheme() {
+	theme.StyleBox(w.Box)
+	w.Clear().
+		AddText("["+theme.HexPrimary+"]"+w.header+"["+theme.HexText+"]", true, tview.AlignLeft, theme.Primary).
 		AddText("", false, tview.AlignLeft, theme.Text)
+	theme.Apply(w.GetPrimitive())
+}
+
+func CreateFileListWrapper(fileList *tview.List) *Wrapper {
+	fileListWrapper := &Wrapper{tview.NewFrame(fileList), "Files"}
 	fileListWrapper.SetBorder(true).SetTitle("Files")
+	fileListWrapper.ApplyTheme()
 	return fileListWrapper
 }
 
-func CreateFormWrapper(form *tview.Form, title string) *tview.Frame {
-	coloredTitle := "[" + theme.HexPrimary + "]" + title + "[" + theme.HexText + "]"
-	formWrapper := tview.NewFrame(form).
-		AddText(" "+coloredTitle+" ", true, tview.AlignLeft, theme.Primary).
-		AddText("", false, tview.AlignLeft, theme.Text)
+func CreateFormWrapper(form *tview.Form, title string) *Wrapper {
+	formWrapper := &Wrapper{tview.NewFrame(form), " " + title + " "}
+	formWrapper.ApplyTheme()
 	return formWrapper
 }
@@ -0,0 +1,248 @@
+package ui
+
+import (
+	"path/filepath"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"id3v2-tui/internal/theme"
+	"id3v2-tui/internal/vim"
+)
+
+type CommandLine struct {
+	*tview.InputField
+}
+
+func (c *CommandLine) ApplyTheme() {
+	c.SetBackgroundColor(theme.Background)
+	c.SetLabelColor(theme.Primary)
+	c.SetFieldBackgroundColor(theme.Background)
+	c.SetFieldTextColor(theme.Text)
+}
+
+func CreateCommandLine() *CommandLine {
+	cmdline := &CommandLine{tview.NewInputField()}
+	cmdline.ApplyTheme()
+	return cmdline
+}
+
+func setMode(ctx *UIContext, mode vim.Mode) {
+	ctx.Vim.Mode = mode
+	ctx.Vim.Reset()
+	if ctx.ModeChanged != nil {
+		ctx.ModeChanged()
+	}
+}
+
+func listItems(list *tview.List) []string {
+	items := make([]string, list.GetItemCount())
+	for i := range items {
+		items[i], _ = list.GetItemText(i)
+	}
+	return items
+}
+
+func formItems(form *tview.Form) []string {
+	items := make([]string, form.GetFormItemCount())
+	for i := range items {
+		item := form.GetFormItem(i)
+		items[i] = item.GetLabel()
+		if field, ok := item.(*tview.InputField); ok {
+			items[i] += " " + field.GetText()
+		}
+	}
+	return items
+}
+
+func moveList(list *tview.List, index int) {
+	if index < 0 {
+		index = 0
+	}
+	if last := list.GetItemCount() - 1; index > last {
+		index = last
+	}
+	list.SetCurrentItem(index)
+}
+
+func search(directMode bool, ctx *UIContext, inList, backward bool) {
+	pattern := ctx.Vim.Pattern
+	if table, ok := ctx.App.GetFocus().(*LibraryTable); ok {
+		if idx := vim.Match(table.Names(), pattern, table.SelectedIndex(), backward); idx >= 0 {
+			table.SelectIndex(idx)
+			return
+		}
+	} else if inList {
+		list := ctx.GetFileList()
+		if idx := vim.Match(listItems(list), pattern, list.GetCurrentItem(), backward); idx >= 0 {
+			list.SetCurrentItem(idx)
+			return
+		}
+	} else {
+		form := ctx.GetForm()
+		current, _ := form.GetFocusedItemIndex()
+		if idx := vim.Match(formItems(form), pattern, current, backward); idx >= 0 {
+			focusField(directMode, ctx, idx)
+			return
+		}
+	}
+	ctx.ShowError("Pattern not found: " + pattern)
+}
+
+func runTableMotion(ctx *UIContext, table *LibraryTable, cmd vim.Command) bool {
+	switch cmd.Action {
+	case vim.Down:
+		table.Move(cmd.Times())
+	case vim.Up:
+		table.Move(-cmd.Times())
+	case vim.Top:
+		table.SelectIndex(0)
+	case vim.Bottom:
+		index := len(table.Entries) - 1
+		if cmd.Count > 0 {
+			index = cmd.Count - 1
+		}
+		table.SelectIndex(index)
+	case vim.Right:
+		table.InputHandler()(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), func(p tview.Primitive) {
+			ctx.App.SetFocus(p)
+		})
+	case vim.Left:
+	default:
+		return false
+	}
+	return true
+}
+
+func runMotion(directMode bool, ctx *UIContext, cmd vim.Command) {
+	if table, ok := ctx.App.GetFocus().(*LibraryTable); ok && runTableMotion(ctx, table, cmd) {
+		return
+	}
+	list := ctx.GetFileList()
+	inList := !directMode && ctx.App.GetFocus() == browser(ctx)
+	form := ctx.GetForm()
+
+	switch cmd.Action {
+	case vim.Down, vim.Up:
+		step := 1
+		if cmd.Action == vim.Up {
+			step = -1
+		}
+		if inList {
+			moveList(list, list.GetCurrentItem()+step*cmd.Times())
+			return
+		}
+		for i := 0; i < cmd.Times(); i++ {
+			focusNext(directMode, ctx, step)
+		}
+	case vim.Top:
+		if inList {
+			moveList(list, 0)
+		} else {
+			focusField(directMode, ctx, 0)
+		}
+	case vim.Bottom:
+		if inList {
+			index := list.GetItemCount() - 1
+			if cmd.Count > 0 {
+				index = cmd.Count - 1
+			}
+			moveList(list, index)
+		} else {
+			focusField(directMode, ctx, form.GetFormItemCount()-1)
+		}
+	case vim.Left:
+		if inList {
+			ctx.LoadDirectory(filepath.Dir(ctx.GetCurrentDir()))
+		} else if !directMode {
+			focusAt(directMode, ctx, 0)
+		}
+	case vim.Right:
+		if inList {
+			list.InputHandler()(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), func(p tview.Primitive) {
+				ctx.App.SetFocus(p)
+			})
+		}
+	case vim.InsertMode:
+		if inList {
+			focusField(directMode, ctx, 0)
+		}
+		if _, ok := ctx.App.GetFocus().(*tview.InputField); ok {
+			setMode(ctx, vim.Insert)
+		}
+	case vim.Search:
+		if inList && ctx.Filter != nil {
+			ctx.Filter()
+			return
+		}
+		ctx.OpenCommandLine("/", func(text string) {
+			ctx.Vim.Pattern = text
+			search(directMode, ctx, inList, false)
+		})
+	case vim.SearchNext, vim.SearchPrev:
+		if ctx.Vim.Pattern != "" {
+			search(directMode, ctx, inList, cmd.Action == vim.SearchPrev)
+		}
+	case vim.CommandLine:
+		ctx.OpenCommandLine(":", func(text string) {
+			runEx(directMode, ctx, text)
+		})
+	}
+}
+
+func runEx(directMode bool, ctx *UIContext, line string) {
+	ex, err := vim.ParseEx(line)
+	if err != nil {
+		return
+	}
+
+	switch ex.Name {
+	case "quit":
+		if ex.Bang {
+			ctx.App.Stop()
+			return
+		}
+	case "write_quit":
+		saveForm(directMode, ctx, ctx.App.Stop)
+		return
+	case "rename":
+		if ex.Arg != "" && !directMode {
+			ctx.PreviewRename(ex.Arg)
+			return
+		}
+	}
+
+	if registry(directMode, ctx).Run(ex.Name) {
+		return
+	}
+	ctx.ShowError("Not an editor command: " + line)
+}
+
+func handleVim(directMode bool, ctx *UIContext, event *tcell.EventKey) bool {
+	_, typing := ctx.App.GetFocus().(*tview.InputField)
+	if ctx.Vim.Mode == vim.Insert {
+		if !typing {
+			setMode(ctx, vim.Normal)
+		} else if event.Key() == tcell.KeyEscape {
+			setMode(ctx, vim.Normal)
+			return true
+		}
+		return false
+	}
+
+	switch event.Key() {
+	case tcell.KeyEscape:
+		ctx.Vim.Reset()
+		return true
+	case tcell.KeyRune:
+		if event.Modifiers()&(tcell.ModCtrl|tcell.ModAlt) != 0 {
+			return false
+		}
+		cmd, ok := ctx.Vim.Key(event.Rune())
+		if ok {
+			runMotion(directMode, ctx, cmd)
+		}
+		return ok || ctx.Vim.Pending()
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package vim
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Mode int
+
+const (
+	Normal Mode = iota
+	Insert
+)
+
+func (m Mode) String() string {
+	if m == Insert {
+		return "INSERT"
+	}
+	return "NORMAL"
+}
+
+const (
+	Down        = "down"
+	Up          = "up"
+	Top         = "top"
+	Bottom      = "bottom"
+	Left        = "left"
+	Right       = "right"
+	InsertMode  = "insert"
+	Search      = "search"
+	SearchNext  = "search_next"
+	SearchPrev  = "search_prev"
+	CommandLine = "command_line"
+)
+
+var motions = map[string]string{
+	"j":  Down,
+	"k":  Up,
+	"gg": Top,
+	"G":  Bottom,
+	"h":  Left,
+	"l":  Right,
+	"i":  InsertMode,
+	"/":  Search,
+	"n":  SearchNext,
+	"N":  SearchPrev,
+	":":  CommandLine,
+}
+
+const Help = `Vim mode
+  j, k               Move down / up (with a count, e.g. 5j)
+  gg, G              First / last entry (3G jumps to entry 3)
+  h, l               Parent directory / open entry
+  i                  Edit the focused field (insert mode)
+  esc                Back to normal mode
+  /                  Filter the file list or search the form
+  n, N               Next / previous form match
+  :w, :q, :wq        Save, quit, save and quit
+  :rename [template] Rename files from tags
+  :<action>          Run any action by name, e.g. :number_tracks
+`
+
+type Command struct {
+	Action string
+	Count  int
+}
+
+type State struct {
+	Mode    Mode
+	Pattern string
+	pending string
+	count   int
+}
+
+func (s *State) Reset() {
+	s.pending = ""
+	s.count = 0
+}
+
+func (s *State) Pending() bool {
+	return s.pending != "" || s.count > 0
+}
+
+func (s *State) Key(r rune) (Command, bool) {
+	if r >= '1' && r <= '9' || r == '0' && s.count > 0 {
+		s.count = s.count*10 + int(r-'0')
+		return Command{}, false
+	}
+
+	seq := s.pending + string(r)
+	action, ok := motions[seq]
+	if !ok {
+		if seq == "g" {
+			s.pending = seq
+			return Command{}, false
+		}
+		s.Reset()
+		return Command{}, false
+	}
+
+	cmd := Command{Action: action, Count: s.count}
+	s.Reset()
+	return cmd, true
+}
+
+func (c Command) Times() int {
+	if c.Count < 1 {
+		return 1
+	}
+	return c.Count
+}
+
+type Ex struct {
+	Name string
+	Arg  string
+	Bang bool
+}
+
+var exAliases = map[string]string{
+	"w":     "save",
+	"write": "save",
+	"q":     "quit",
+	"quit":  "quit",
+	"wq":    "write_quit",
+	"x":     "write_quit",
+	"h":     "help",
+	"help":  "help",
+}
+
+func ParseEx(line string) (Ex, error) {
+	line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), ":"))
+	if line == "" {
+		return Ex{}, fmt.Errorf("empty command")
+	}
+
+	name, arg, _ := strings.Cut(line, " ")
+	ex := Ex{Arg: strings.TrimSpace(arg)}
+	if strings.HasSuffix(name, "!") {
+		ex.Bang = true
+		name = strings.TrimSuffix(name, "!")
+	}
+	if alias, ok := exAliases[name]; ok {
+		name = alias
+	}
+	ex.Name = name
+	return ex, nil
+}
+
+func Match(items []string, pattern string, from int, backward bool) int {
+	if pattern == "" || len(items) == 0 {
+		return -1
+	}
+	pattern = strings.ToLower(pattern)
+	step := 1
+	if backward {
+		step = -1
+	}
+	for i := 1; i <= len(items); i++ {
+		idx := ((from+step*i)%len(items) + len(items)) % len(items)
+		if strings.Contains(strings.ToLower(items[idx]), pattern) {
+			return idx
+		}
+	}
+	return -1
+}
@@ -0,0 +1,109 @@
+package vim
+
+import "testing"
+
+func feed(s *State, keys string) (Command, bool) {
+	var cmd Command
+	var ok bool
+	for _, r := range keys {
+		cmd, ok = s.Key(r)
+	}
+	return cmd, ok
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		keys  string
+		want  string
+		count int
+	}{
+		{"j", Down, 0},
+		{"k", Up, 0},
+		{"gg", Top, 0},
+		{"G", Bottom, 0},
+		{"12G", Bottom, 12},
+		{"5j", Down, 5},
+		{"10k", Up, 10},
+		{"h", Left, 0},
+		{"l", Right, 0},
+		{"i", InsertMode, 0},
+		{"/", Search, 0},
+		{":", CommandLine, 0},
+	}
+
+	for _, tt := range tests {
+		s := &State{}
+		cmd, ok := feed(s, tt.keys)
+		if !ok || cmd.Action != tt.want || cmd.Count != tt.count {
+			t.Errorf("%q = %+v, %v; want %s x%d", tt.keys, cmd, ok, tt.want, tt.count)
+		}
+		if s.Pending() {
+			t.Errorf("%q left a pending sequence", tt.keys)
+		}
+	}
+}
+
+func TestKeyPending(t *testing.T) {
+	s := &State{}
+	if _, ok := s.Key('g'); ok || !s.Pending() {
+		t.Fatal("g should wait for a second key")
+	}
+	if _, ok := s.Key('x'); ok || s.Pending() {
+		t.Error("an unknown sequence should be dropped")
+	}
+
+	if _, ok := s.Key('0'); ok || s.Pending() {
+		t.Error("a leading 0 is not a count")
+	}
+	s.Key('3')
+	s.Reset()
+	if cmd, _ := s.Key('j'); cmd.Times() != 1 {
+		t.Errorf("Reset should drop the count, got %d", cmd.Times())
+	}
+}
+
+func TestParseEx(t *testing.T) {
+	tests := []struct {
+		line string
+		want Ex
+	}{
+		{"w", Ex{Name: "save"}},
+		{":write", Ex{Name: "save"}},
+		{"q!", Ex{Name: "quit", Bang: true}},
+		{"wq", Ex{Name: "write_quit"}},
+		{"x", Ex{Name: "write_quit"}},
+		{"rename %a/%n - %t", Ex{Name: "rename", Arg: "%a/%n - %t"}},
+		{" number_tracks ", Ex{Name: "number_tracks"}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseEx(tt.line)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseEx(%q) = %+v, %v; want %+v", tt.line, got, err, tt.want)
+		}
+	}
+
+	if _, err := ParseEx(" : "); err == nil {
+		t.Error("an empty command should fail")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	items := []string{"..", "Abbey Road/", "Help!/", "come together.mp3", "something.mp3"}
+
+	if got := Match(items, "road", 0, false); got != 1 {
+		t.Errorf("expected match at 1, got %d", got)
+	}
+	if got := Match(items, "mp3", 3, false); got != 4 {
+		t.Errorf("search should start after the current item, got %d", got)
+	}
+	if got := Match(items, "mp3", 4, false); got != 3 {
+		t.Errorf("search should wrap around, got %d", got)
+	}
+	if got := Match(items, "mp3", 3, true); got != 4 {
+		t.Errorf("backward search should wrap around, got %d", got)
+	}
+	if got := Match(items, "revolver", 0, false); got != -1 {
+		t.Errorf("expected no match, got %d", got)
+	}
+}
@@ -5,15 +5,30 @@ import (
 	"os"
 
 	"id3v2-tui/internal/app"
+	"id3v2-tui/internal/cli"
+	"id3v2-tui/internal/config"
 )
 
 func main() {
+	cfg, err := config.Load(config.Path())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid configuration:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	cfg.Apply()
+
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
 	filePath := ""
 	if len(os.Args) > 1 {
 		filePath = os.Args[1]
 	}
 
 	a := app.NewApp()
+	a.SetConfig(cfg)
 	if err := a.Run(filePath); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)